@@ -24,6 +24,7 @@ type Entry struct {
 	FindingID string `json:"finding_id"`
 	RuleID    string `json:"rule_id"`
 	File      string `json:"file"`
+	Address   string `json:"address,omitempty"`
 	LineHash  string `json:"line_hash"`
 	Status    string `json:"status"`
 	Reason    string `json:"reason"`
@@ -89,7 +90,7 @@ func Save(path string, b File) error {
 
 func IsSuppressed(b File, f model.Finding) bool {
 	for _, e := range b.Entries {
-		if e.RuleID == f.RuleID && e.File == f.Location.File && e.LineHash == f.LineHash {
+		if e.RuleID == f.RuleID && e.File == f.Location.File && e.Address == f.Location.Address && e.LineHash == f.LineHash {
 			return true
 		}
 	}
@@ -103,7 +104,7 @@ func UpsertEntries(base File, findings []model.Finding, status string, reason st
 	}
 	index := map[string]int{}
 	for i, e := range out.Entries {
-		key := e.RuleID + "|" + e.File + "|" + e.LineHash
+		key := e.RuleID + "|" + e.File + "|" + e.Address + "|" + e.LineHash
 		index[key] = i
 	}
 
@@ -113,13 +114,14 @@ func UpsertEntries(base File, findings []model.Finding, status string, reason st
 			FindingID: f.ID,
 			RuleID:    f.RuleID,
 			File:      f.Location.File,
+			Address:   f.Location.Address,
 			LineHash:  f.LineHash,
 			Status:    status,
 			Reason:    reason,
 			AddedAt:   now,
 			AddedBy:   by,
 		}
-		key := entry.RuleID + "|" + entry.File + "|" + entry.LineHash
+		key := entry.RuleID + "|" + entry.File + "|" + entry.Address + "|" + entry.LineHash
 		if idx, ok := index[key]; ok {
 			out.Entries[idx] = entry
 			continue
@@ -0,0 +1,46 @@
+package cli
+
+import (
+	"fmt"
+
+	"github.com/peter941221/secrethawk/internal/scan"
+	"github.com/spf13/cobra"
+)
+
+func newCacheCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "cache",
+		Short: "Manage the incremental scan cache",
+	}
+
+	cmd.AddCommand(newCacheClearCommand())
+
+	return cmd
+}
+
+func newCacheClearCommand() *cobra.Command {
+	var dir string
+
+	cmd := &cobra.Command{
+		Use:   "clear [target]",
+		Short: "Delete cached scan results",
+		Args:  cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if dir == "" {
+				target := "."
+				if len(args) > 0 {
+					target = args[0]
+				}
+				dir = scan.CacheDirFor(target)
+			}
+			if err := scan.ClearCache(dir); err != nil {
+				return &ExitError{Code: 2, Message: err.Error()}
+			}
+			fmt.Fprintf(cmd.OutOrStdout(), "cache cleared: %s\n", dir)
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&dir, "dir", "", "Cache directory (default <target>/"+scan.DefaultCacheDir+", as used by scan)")
+	return cmd
+}
@@ -5,8 +5,6 @@ import (
 	"encoding/json"
 	"fmt"
 	"os"
-	"path/filepath"
-	"strings"
 	"time"
 
 	"github.com/peter941221/secrethawk/internal/baseline"
@@ -28,50 +26,6 @@ func loadFindingReport(path string) (model.FindingReport, error) {
 	return report, nil
 }
 
-func resolveRulesDir(defaultDir string) (string, error) {
-	if defaultDir == "" {
-		defaultDir = "rules"
-	}
-	if info, err := os.Stat(defaultDir); err == nil && info.IsDir() && hasYAML(defaultDir) {
-		return defaultDir, nil
-	}
-
-	cwd, err := os.Getwd()
-	if err != nil {
-		return "", err
-	}
-	cur := cwd
-	for {
-		candidate := filepath.Join(cur, defaultDir)
-		if info, err := os.Stat(candidate); err == nil && info.IsDir() && hasYAML(candidate) {
-			return candidate, nil
-		}
-		parent := filepath.Dir(cur)
-		if parent == cur {
-			break
-		}
-		cur = parent
-	}
-	return "", fmt.Errorf("rules directory not found: %s", defaultDir)
-}
-
-func hasYAML(dir string) bool {
-	entries, err := os.ReadDir(dir)
-	if err != nil {
-		return false
-	}
-	for _, e := range entries {
-		if e.IsDir() {
-			continue
-		}
-		ext := strings.ToLower(filepath.Ext(e.Name()))
-		if ext == ".yaml" || ext == ".yml" {
-			return true
-		}
-	}
-	return false
-}
-
 func collectFindingsFromInputOrScan(input string, target string, policyPath string, rulesPath string, baselinePath string) ([]model.Finding, error) {
 	if input != "" {
 		report, err := loadFindingReport(input)
@@ -97,8 +51,8 @@ func collectFindingsFromInputOrScan(input string, target string, policyPath stri
 	return res.Report.Findings, nil
 }
 
-func runPolicyTests(defaultRulesDir string, customRulesPath string) (int, int, error) {
-	loaded, err := rules.Load(defaultRulesDir, customRulesPath)
+func runPolicyTests(customRulesPath string) (int, int, error) {
+	loaded, err := rules.LoadBuiltin(customRulesPath)
 	if err != nil {
 		return 0, 0, err
 	}
@@ -106,12 +60,21 @@ func runPolicyTests(defaultRulesDir string, customRulesPath string) (int, int, e
 	pass := 0
 	fail := 0
 	for _, r := range loaded {
+		if r.Type != rules.TypeRegex && r.Type != rules.TypeJWT && r.Type != rules.TypeURI && r.Type != rules.TypeKeyValue {
+			continue
+		}
 		if len(r.Tests.Positive) < 2 || len(r.Tests.Negative) < 2 {
 			fail++
 			continue
 		}
 
 		for _, tc := range r.Tests.Positive {
+			// A positive case without a declared keyword would be skipped by
+			// the scan prefilter even though the regex matches.
+			if !rules.HasKeyword(r, tc.Input) {
+				fail++
+				continue
+			}
 			got := rules.TestRuleAgainstInput(r, tc.Input)
 			if got == tc.ShouldMatch {
 				pass++
@@ -7,6 +7,7 @@ import (
 	"strings"
 	"time"
 
+	"github.com/peter941221/secrethawk/internal/model"
 	"github.com/spf13/cobra"
 )
 
@@ -17,6 +18,7 @@ func newHistoryCleanCommand() *cobra.Command {
 		method    string
 		backup    bool
 		secret    string
+		input     string
 	)
 
 	cmd := &cobra.Command{
@@ -27,6 +29,19 @@ func newHistoryCleanCommand() *cobra.Command {
 				return &ExitError{Code: 2, Message: "provide --all, --finding-id, or --secret"}
 			}
 
+			var findings []model.Finding
+			if input != "" {
+				report, err := loadFindingReport(input)
+				if err != nil {
+					return &ExitError{Code: 2, Message: err.Error()}
+				}
+				for _, f := range report.Findings {
+					if findingID == "" || f.ID == findingID {
+						findings = append(findings, f)
+					}
+				}
+			}
+
 			dirty, err := gitDirty()
 			if err != nil {
 				return &ExitError{Code: 2, Message: err.Error()}
@@ -54,8 +69,7 @@ func newHistoryCleanCommand() *cobra.Command {
 				if err := os.MkdirAll(".secrethawk/tmp", 0o755); err != nil {
 					return &ExitError{Code: 2, Message: err.Error()}
 				}
-				line := fmt.Sprintf("%s==>%s\n", secret, "<REDACTED_BY_SECRETHAWK>")
-				if err := os.WriteFile(replaceFile, []byte(line), 0o600); err != nil {
+				if err := os.WriteFile(replaceFile, []byte(filterRepoReplacements(secret)), 0o600); err != nil {
 					return &ExitError{Code: 2, Message: err.Error()}
 				}
 				if err := runCommand("git", "filter-repo", "--force", "--replace-text", replaceFile); err != nil {
@@ -69,6 +83,12 @@ func newHistoryCleanCommand() *cobra.Command {
 			}
 
 			fmt.Fprintln(cmd.OutOrStdout(), "warning: local history cleaned. force push and notify collaborators to rebase.")
+			if steps := unreachableCleanupSteps(findings); len(steps) > 0 {
+				fmt.Fprintln(cmd.OutOrStdout(), "warning: secrets were also found outside branch history and survive a rewrite until expired. run:")
+				for _, step := range steps {
+					fmt.Fprintf(cmd.OutOrStdout(), "  %s\n", step)
+				}
+			}
 			return nil
 		},
 	}
@@ -78,10 +98,48 @@ func newHistoryCleanCommand() *cobra.Command {
 	cmd.Flags().StringVar(&method, "method", "bfg", "Method: bfg|filter-repo|rebase")
 	cmd.Flags().BoolVar(&backup, "backup", true, "Create backup branch before cleanup")
 	cmd.Flags().StringVar(&secret, "secret", "", "Raw secret to scrub (required for filter-repo)")
+	cmd.Flags().StringVar(&input, "input", "", "Findings JSON from scan --deep-history; prints stash/reflog/gc follow-up when needed")
 
 	return cmd
 }
 
+// unreachableCleanupSteps returns the git commands still needed after a
+// rewrite when findings were seen in stashes, reflogs or unreachable objects,
+// which history rewriting tools leave in place.
+func unreachableCleanupSteps(findings []model.Finding) []string {
+	sources := map[string]bool{}
+	for _, f := range findings {
+		sources[f.Location.Source] = true
+		for _, o := range f.Occurrences {
+			sources[o.Source] = true
+		}
+	}
+	steps := make([]string, 0, 3)
+	if sources["stash"] {
+		steps = append(steps, "git stash clear")
+	}
+	if sources["stash"] || sources["reflog"] || sources["unreachable"] || sources["dangling-blob"] {
+		steps = append(steps, "git reflog expire --expire=now --all", "git gc --prune=now")
+	}
+	return steps
+}
+
+// filterRepoReplacements renders --replace-text rules. The file format is one
+// expression per line, so multi-line secrets such as PEM blocks are scrubbed
+// line by line. PEM armour and header lines are shared by every key and
+// certificate in history, so only the base64 body lines are replaced.
+func filterRepoReplacements(secret string) string {
+	var b strings.Builder
+	for _, line := range strings.Split(strings.ReplaceAll(secret, "\r\n", "\n"), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "-----") || strings.Contains(line, ": ") {
+			continue
+		}
+		b.WriteString(fmt.Sprintf("%s==>%s\n", line, "<REDACTED_BY_SECRETHAWK>"))
+	}
+	return b.String()
+}
+
 func gitDirty() (bool, error) {
 	cmd := exec.Command("git", "status", "--porcelain")
 	out, err := cmd.CombinedOutput()
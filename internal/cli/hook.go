@@ -0,0 +1,119 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/peter941221/secrethawk/internal/config"
+	"github.com/peter941221/secrethawk/internal/model"
+	"github.com/peter941221/secrethawk/internal/output"
+	"github.com/peter941221/secrethawk/internal/scan"
+	"github.com/peter941221/secrethawk/internal/severity"
+	"github.com/spf13/cobra"
+)
+
+// defaultHookConfigDir holds the server-side policy.yaml, baseline.json and
+// optional rules/ directory used by hook commands.
+const defaultHookConfigDir = "/etc/secrethawk"
+
+func newHookCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "hook",
+		Short: "Run as a server-side git hook",
+	}
+
+	cmd.AddCommand(newHookPreReceiveCommand())
+
+	return cmd
+}
+
+func newHookPreReceiveCommand() *cobra.Command {
+	var configDir string
+	var repo string
+
+	cmd := &cobra.Command{
+		Use:   "pre-receive",
+		Short: "Reject pushes whose new commits add secrets",
+		Long: "Reads \"<old> <new> <ref>\" lines from stdin as git passes them to a pre-receive hook, " +
+			"scans the lines added by every new commit without a checkout and exits non-zero when " +
+			"findings reach the policy's severity.block_on level.",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			updates, err := scan.ParseRefUpdates(cmd.InOrStdin())
+			if err != nil {
+				return &ExitError{Code: 2, Message: err.Error()}
+			}
+
+			policyPath := filepath.Join(configDir, "policy.yaml")
+			policy, err := config.LoadPolicy(policyPath)
+			if err != nil {
+				return &ExitError{Code: 2, Message: err.Error()}
+			}
+			rulesPath := filepath.Join(configDir, "rules")
+			if info, err := os.Stat(rulesPath); err != nil || !info.IsDir() {
+				rulesPath = ""
+			}
+
+			result, err := scan.Run(context.Background(), scan.Options{
+				Target:             repo,
+				RefUpdates:         updates,
+				RulesPath:          rulesPath,
+				PolicyPath:         policyPath,
+				BaselinePath:       filepath.Join(configDir, "baseline.json"),
+				Severity:           "low",
+				FailOn:             policy.Severity.BlockOn,
+				MaxTargetMegabytes: 50,
+				Version:            BuildVersion,
+				Now:                time.Now().UTC(),
+			})
+			if err != nil {
+				return &ExitError{Code: 2, Message: err.Error()}
+			}
+			if !result.ShouldFail {
+				return nil
+			}
+
+			writeRejection(cmd.ErrOrStderr(), result.Report.Findings, policy.Severity.BlockOn)
+			return &ExitError{Code: 1, Message: "push rejected: secrets found in new commits"}
+		},
+	}
+
+	defaultDir := os.Getenv("SECRETHAWK_HOOK_CONFIG")
+	if defaultDir == "" {
+		defaultDir = defaultHookConfigDir
+	}
+	cmd.Flags().StringVar(&configDir, "config-dir", defaultDir, "Server-side directory with policy.yaml, baseline.json and optional rules/ (env SECRETHAWK_HOOK_CONFIG)")
+	cmd.Flags().StringVar(&repo, "repo", ".", "Repository receiving the push")
+	return cmd
+}
+
+// writeRejection prints one line per blocking finding in a form that reads
+// well after git prefixes it with "remote: ".
+func writeRejection(w io.Writer, findings []model.Finding, blockOn string) {
+	blocking := make([]model.Finding, 0)
+	for _, f := range findings {
+		if f.Suppression == nil && severity.MeetsOrAbove(f.Severity, blockOn) {
+			blocking = append(blocking, f)
+		}
+	}
+	fmt.Fprintf(w, "secrethawk: push rejected, %d secret(s) found in new commits\n", len(blocking))
+	for _, f := range blocking {
+		commit := ""
+		if f.Location.Commit != nil {
+			commit = *f.Location.Commit
+			if len(commit) > 12 {
+				commit = commit[:12]
+			}
+		}
+		fmt.Fprintf(w, "  %-8s %s  %s  commit %s", strings.ToUpper(f.Severity), f.RuleID, output.LocationString(f.Location), commit)
+		if f.Location.Branch != "" {
+			fmt.Fprintf(w, " (%s)", f.Location.Branch)
+		}
+		fmt.Fprintln(w)
+	}
+	fmt.Fprintln(w, "secrethawk: remove the secret from these commits and push again; ask an administrator to baseline false positives.")
+}
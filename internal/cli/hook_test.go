@@ -0,0 +1,150 @@
+package cli
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestHookPreReceiveRejectsPushedSecret(t *testing.T) {
+	tmp := t.TempDir()
+	t.Setenv("HOME", tmp)
+	t.Setenv("GIT_CONFIG_NOSYSTEM", "1")
+	repo := filepath.Join(tmp, "repo")
+	configDir := filepath.Join(tmp, "hook")
+	for _, dir := range []string{repo, configDir} {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.WriteFile(filepath.Join(configDir, "policy.yaml"), []byte("version: \"1\"\nseverity:\n  block_on: high\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	mustRun(t, repo, "git", "init", "-b", "main")
+	mustRun(t, repo, "git", "config", "user.email", "dev@example.com")
+	mustRun(t, repo, "git", "config", "user.name", "dev")
+	if err := os.WriteFile(filepath.Join(repo, "app.py"), []byte("print('ok')\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	mustRun(t, repo, "git", "add", "-A")
+	mustRun(t, repo, "git", "commit", "-q", "-m", "clean")
+	oldTip := gitRevParse(t, repo, "HEAD")
+	if err := os.WriteFile(filepath.Join(repo, "app.py"), []byte(fmt.Sprintf("print('ok')\nkey = %q\n", testAWSKey())), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	mustRun(t, repo, "git", "commit", "-q", "-am", "leak")
+	newTip := gitRevParse(t, repo, "HEAD")
+	// Unreference the new commit so it looks like an incoming push.
+	mustRun(t, repo, "git", "reset", "-q", "--hard", oldTip)
+
+	run := func(stdin string) (string, error) {
+		root := NewRootCommand()
+		var stderr bytes.Buffer
+		root.SetOut(&bytes.Buffer{})
+		root.SetErr(&stderr)
+		root.SetIn(strings.NewReader(stdin))
+		root.SetArgs([]string{"hook", "pre-receive", "--repo", repo, "--config-dir", configDir})
+		err := root.Execute()
+		return stderr.String(), err
+	}
+
+	zero := strings.Repeat("0", 40)
+	out, err := run(fmt.Sprintf("%s %s refs/heads/main\n%s %s refs/heads/old\n", oldTip, newTip, oldTip, zero))
+	var exitErr *ExitError
+	if !errors.As(err, &exitErr) || exitErr.Code != 1 {
+		t.Fatalf("expected exit code 1, got %v", err)
+	}
+	for _, want := range []string{"aws-access-key-id", "app.py:2", "commit " + newTip[:12], "(main)"} {
+		if !strings.Contains(out, want) {
+			t.Fatalf("expected %q in rejection, got:\n%s", want, out)
+		}
+	}
+
+	if _, err := run(fmt.Sprintf("%s %s refs/heads/feature\n", zero, oldTip)); err != nil {
+		t.Fatalf("expected clean push to pass, got %v", err)
+	}
+}
+
+func TestHookPreReceiveRejectsSecretInEvilMerge(t *testing.T) {
+	tmp := t.TempDir()
+	t.Setenv("HOME", tmp)
+	t.Setenv("GIT_CONFIG_NOSYSTEM", "1")
+	repo := filepath.Join(tmp, "repo")
+	configDir := filepath.Join(tmp, "hook")
+	for _, dir := range []string{repo, configDir} {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.WriteFile(filepath.Join(configDir, "policy.yaml"), []byte("version: \"1\"\nseverity:\n  block_on: high\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	mustRun(t, repo, "git", "init", "-b", "main")
+	mustRun(t, repo, "git", "config", "user.email", "dev@example.com")
+	mustRun(t, repo, "git", "config", "user.name", "dev")
+	if err := os.WriteFile(filepath.Join(repo, "app.py"), []byte("print('ok')\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	mustRun(t, repo, "git", "add", "-A")
+	mustRun(t, repo, "git", "commit", "-q", "-m", "clean")
+	oldTip := gitRevParse(t, repo, "HEAD")
+	mustRun(t, repo, "git", "checkout", "-q", "-b", "side")
+	if err := os.WriteFile(filepath.Join(repo, "README.md"), []byte("docs\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	mustRun(t, repo, "git", "add", "-A")
+	mustRun(t, repo, "git", "commit", "-q", "-m", "docs")
+	mustRun(t, repo, "git", "checkout", "-q", "main")
+	if err := os.WriteFile(filepath.Join(repo, "main.txt"), []byte("main\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	mustRun(t, repo, "git", "add", "-A")
+	mustRun(t, repo, "git", "commit", "-q", "-m", "main")
+	mainTip := gitRevParse(t, repo, "HEAD")
+	// The secret only exists in the merge commit itself, not in either parent.
+	mustRun(t, repo, "git", "merge", "-q", "--no-ff", "--no-commit", "side")
+	if err := os.WriteFile(filepath.Join(repo, "app.py"), []byte(fmt.Sprintf("print('ok')\nkey = %q\n", testAWSKey())), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	mustRun(t, repo, "git", "add", "-A")
+	mustRun(t, repo, "git", "commit", "-q", "-m", "merge side")
+	newTip := gitRevParse(t, repo, "HEAD")
+	mustRun(t, repo, "git", "reset", "-q", "--hard", oldTip)
+	mustRun(t, repo, "git", "branch", "-q", "-f", "side", mainTip)
+
+	root := NewRootCommand()
+	var stderr bytes.Buffer
+	root.SetOut(&bytes.Buffer{})
+	root.SetErr(&stderr)
+	root.SetIn(strings.NewReader(fmt.Sprintf("%s %s refs/heads/main\n", oldTip, newTip)))
+	root.SetArgs([]string{"hook", "pre-receive", "--repo", repo, "--config-dir", configDir})
+	err := root.Execute()
+	var exitErr *ExitError
+	if !errors.As(err, &exitErr) || exitErr.Code != 1 {
+		t.Fatalf("expected exit code 1, got %v", err)
+	}
+	out := stderr.String()
+	for _, want := range []string{"aws-access-key-id", "app.py:2", "commit " + newTip[:12]} {
+		if !strings.Contains(out, want) {
+			t.Fatalf("expected %q in rejection, got:\n%s", want, out)
+		}
+	}
+}
+
+func gitRevParse(t *testing.T, dir string, rev string) string {
+	t.Helper()
+	cmd := exec.Command("git", "rev-parse", rev)
+	cmd.Dir = dir
+	out, err := cmd.Output()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return strings.TrimSpace(string(out))
+}
@@ -148,7 +148,7 @@ func runConnectorRemediation(ctx context.Context, findings []model.Finding, forc
 		if c == nil {
 			continue
 		}
-		if f.RawSecret == "" {
+		if f.RawSecret == "" || f.Suppression != nil {
 			continue
 		}
 
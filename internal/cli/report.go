@@ -8,6 +8,7 @@ import (
 	"time"
 
 	"github.com/peter941221/secrethawk/internal/model"
+	"github.com/peter941221/secrethawk/internal/output"
 	"github.com/spf13/cobra"
 )
 
@@ -71,7 +72,7 @@ func renderIncidentReport(scanReport model.FindingReport, operator string) strin
 	b.WriteString("|-------|-------|\n")
 	b.WriteString(fmt.Sprintf("| Secret Type | %s |\n", first.RuleName))
 	b.WriteString(fmt.Sprintf("| Severity | %s |\n", strings.ToUpper(first.Severity)))
-	b.WriteString(fmt.Sprintf("| File | `%s:%d` |\n", first.Location.File, first.Location.LineStart))
+	b.WriteString(fmt.Sprintf("| File | `%s` |\n", output.LocationString(first.Location)))
 	if first.Location.Commit != nil {
 		b.WriteString(fmt.Sprintf("| Commit | `%s` |\n", *first.Location.Commit))
 	} else {
@@ -88,7 +89,7 @@ func renderIncidentReport(scanReport model.FindingReport, operator string) strin
 	for i, f := range scanReport.Findings {
 		b.WriteString(fmt.Sprintf("### %d. %s\n\n", i+1, f.RuleName))
 		b.WriteString(fmt.Sprintf("- Severity: `%s`\n", f.Severity))
-		b.WriteString(fmt.Sprintf("- Location: `%s:%d`\n", f.Location.File, f.Location.LineStart))
+		b.WriteString(fmt.Sprintf("- Location: `%s`\n", output.LocationString(f.Location)))
 		b.WriteString(fmt.Sprintf("- Match (redacted): `%s`\n", f.Match.RawRedacted))
 		b.WriteString(fmt.Sprintf("- Validation: `%s`\n\n", f.Validation.Status))
 	}
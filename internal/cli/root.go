@@ -31,8 +31,11 @@ func NewRootCommand() *cobra.Command {
 		newHistoryCleanCommand(),
 		newReportCommand(),
 		newPolicyCommand(),
+		newRulesCommand(),
 		newConnectorCommand(),
 		newBaselineCommand(),
+		newCacheCommand(),
+		newHookCommand(),
 		newGrowthCommand(),
 		newVersionCommand(),
 	)
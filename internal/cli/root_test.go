@@ -1,6 +1,11 @@
 package cli
 
-import "testing"
+import (
+	"bytes"
+	"os"
+	"strings"
+	"testing"
+)
 
 func TestRootCommandContainsTopLevelCommands(t *testing.T) {
 	root := NewRootCommand()
@@ -13,8 +18,11 @@ func TestRootCommandContainsTopLevelCommands(t *testing.T) {
 		"history-clean",
 		"report",
 		"policy",
+		"rules",
 		"connector",
 		"baseline",
+		"cache",
+		"hook",
 		"growth",
 		"version",
 	}
@@ -41,6 +49,28 @@ func TestPolicyCommandContainsSubcommands(t *testing.T) {
 	}
 }
 
+func TestRulesListUsesEmbeddedCatalogOutsideCheckout(t *testing.T) {
+	orig, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Chdir(orig)
+	if err := os.Chdir(t.TempDir()); err != nil {
+		t.Fatal(err)
+	}
+
+	root := NewRootCommand()
+	var out bytes.Buffer
+	root.SetOut(&out)
+	root.SetArgs([]string{"rules", "list"})
+	if err := root.Execute(); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out.String(), "aws-access-key-id            critical  regex     aws") {
+		t.Fatalf("expected aws rule with connector, got:\n%s", out.String())
+	}
+}
+
 func findCommand(parent interface{ Commands() []*Command }, name string) *Command {
 	for _, c := range parent.Commands() {
 		if c.Name() == name {
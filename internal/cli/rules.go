@@ -0,0 +1,56 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/peter941221/secrethawk/internal/rules"
+	"github.com/spf13/cobra"
+)
+
+func newRulesCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "rules",
+		Short: "Inspect the detection rule catalog",
+	}
+
+	cmd.AddCommand(newRulesListCommand())
+
+	return cmd
+}
+
+func newRulesListCommand() *cobra.Command {
+	var customRulesPath string
+
+	cmd := &cobra.Command{
+		Use:   "list",
+		Short: "List the built-in rules, merged with --rules when given",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			loaded, err := rules.LoadBuiltin(customRulesPath)
+			if err != nil {
+				return &ExitError{Code: 2, Message: err.Error()}
+			}
+			writeRuleList(cmd.OutOrStdout(), loaded)
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&customRulesPath, "rules", "", "Custom rules path to add to or override the built-in catalog")
+	return cmd
+}
+
+func writeRuleList(w io.Writer, loaded []rules.Rule) {
+	fmt.Fprintf(w, "rule catalog %s (%d rules)\n", rules.CatalogVersion, len(loaded))
+	fmt.Fprintf(w, "%-28s %-9s %-9s %s\n", "ID", "SEVERITY", "TYPE", "CONNECTOR")
+	for _, r := range loaded {
+		typ := r.Type
+		if typ == "" {
+			typ = rules.TypeRegex
+		}
+		conn := r.Validation.Connector
+		if conn == "" {
+			conn = "-"
+		}
+		fmt.Fprintf(w, "%-28s %-9s %-9s %s\n", r.ID, r.Severity, typ, conn)
+	}
+}
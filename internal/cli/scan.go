@@ -16,6 +16,7 @@ type scanOptions struct {
 	Staged             bool
 	SinceRef           string
 	AllHistory         bool
+	DeepHistory        bool
 	RulesPath          string
 	PolicyPath         string
 	BaselinePath       string
@@ -23,10 +24,15 @@ type scanOptions struct {
 	OutputPath         string
 	Severity           string
 	Validate           bool
+	Offline            bool
 	FailOn             string
 	FailOnActive       bool
 	MaxTargetMegabytes int
 	Threads            int
+	StdinFilename      string
+	ImageTar           string
+	NoCache            bool
+	NoGitignore        bool
 }
 
 func newScanCommand() *cobra.Command {
@@ -34,35 +40,48 @@ func newScanCommand() *cobra.Command {
 
 	cmd := &cobra.Command{
 		Use:   "scan [target]",
-		Short: "Scan for secret leaks",
+		Short: "Scan for secret leaks (use - to read from stdin)",
 		Args:  cobra.MaximumNArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
 			target := "."
 			if len(args) == 1 {
 				target = args[0]
 			}
+			if opts.StdinFilename != "" && target != "-" {
+				return &ExitError{Code: 2, Message: "--stdin-filename requires target -"}
+			}
+			if opts.Offline && opts.FailOnActive {
+				// Offline checks never mark a finding active.
+				return &ExitError{Code: 2, Message: "--fail-on-active cannot be combined with --offline"}
+			}
 
 			runOpts := scan.Options{
 				Target:             target,
 				Staged:             opts.Staged,
 				SinceRef:           opts.SinceRef,
 				AllHistory:         opts.AllHistory,
+				DeepHistory:        opts.DeepHistory,
 				RulesPath:          opts.RulesPath,
 				PolicyPath:         opts.PolicyPath,
 				BaselinePath:       opts.BaselinePath,
 				Severity:           strings.ToLower(opts.Severity),
-				Validate:           opts.Validate || opts.FailOnActive,
+				Validate:           opts.Validate || opts.FailOnActive || opts.Offline,
+				OfflineValidation:  opts.Offline,
 				FailOn:             strings.ToLower(opts.FailOn),
 				FailOnActive:       opts.FailOnActive,
 				MaxTargetMegabytes: opts.MaxTargetMegabytes,
 				Threads:            opts.Threads,
+				ImageTar:           opts.ImageTar,
+				NoGitignore:        opts.NoGitignore,
 				Version:            BuildVersion,
 				Now:                time.Now().UTC(),
 			}
-
-			result, err := scan.Run(context.Background(), runOpts)
-			if err != nil {
-				return &ExitError{Code: 2, Message: err.Error()}
+			if !opts.NoCache && target != "-" && opts.ImageTar == "" {
+				runOpts.CacheDir = scan.CacheDirFor(target)
+			}
+			if target == "-" {
+				runOpts.Stdin = cmd.InOrStdin()
+				runOpts.StdinFilename = opts.StdinFilename
 			}
 
 			writer := cmd.OutOrStdout()
@@ -75,7 +94,30 @@ func newScanCommand() *cobra.Command {
 				writer = file
 			}
 
-			if err := output.Write(result.Report, opts.Format, writer); err != nil {
+			var result scan.Result
+			var err error
+			var stream *output.NDJSONWriter
+			if strings.EqualFold(opts.Format, "ndjson") {
+				// Stream findings as workers produce them instead of
+				// waiting for the sorted report.
+				stream = output.NewNDJSONWriter(writer)
+				result, err = scan.RunStream(context.Background(), runOpts, stream.WriteFinding)
+			} else {
+				result, err = scan.Run(context.Background(), runOpts)
+			}
+			if err != nil {
+				return &ExitError{Code: 2, Message: err.Error()}
+			}
+			for _, w := range result.Warnings {
+				fmt.Fprintf(cmd.ErrOrStderr(), "warning: %s\n", w)
+			}
+
+			if stream != nil {
+				err = stream.WriteMetadata(result.Report.Metadata)
+			} else {
+				err = output.Write(result.Report, opts.Format, writer)
+			}
+			if err != nil {
 				return &ExitError{Code: 2, Message: err.Error()}
 			}
 
@@ -89,17 +131,23 @@ func newScanCommand() *cobra.Command {
 	cmd.Flags().BoolVar(&opts.Staged, "staged", false, "Scan only staged files")
 	cmd.Flags().StringVar(&opts.SinceRef, "since", "", "Scan changes since commit/branch ref")
 	cmd.Flags().BoolVar(&opts.AllHistory, "all-history", false, "Scan complete git history")
+	cmd.Flags().BoolVar(&opts.DeepHistory, "deep-history", false, "Scan complete git history plus stashes, reflog entries and unreachable objects")
 	cmd.Flags().StringVar(&opts.RulesPath, "rules", "", "Path to custom rules")
 	cmd.Flags().StringVar(&opts.PolicyPath, "policy", ".secrethawk/policy.yaml", "Policy file path")
 	cmd.Flags().StringVar(&opts.BaselinePath, "baseline", ".secrethawk/baseline.json", "Baseline file path")
-	cmd.Flags().StringVar(&opts.Format, "format", "human", "Output format: human|json|sarif")
+	cmd.Flags().StringVar(&opts.Format, "format", "human", "Output format: human|json|sarif|ndjson")
 	cmd.Flags().StringVar(&opts.OutputPath, "output", "", "Output file path")
 	cmd.Flags().StringVar(&opts.Severity, "severity", "low", "Minimum reported severity")
 	cmd.Flags().BoolVar(&opts.Validate, "validate", false, "Validate whether secrets are active")
+	cmd.Flags().BoolVar(&opts.Offline, "offline", false, "Validate token structure and checksums only; never contact provider APIs (implies --validate)")
 	cmd.Flags().StringVar(&opts.FailOn, "fail-on", "", "Exit non-zero when findings >= severity")
 	cmd.Flags().BoolVar(&opts.FailOnActive, "fail-on-active", false, "Only fail when validated ACTIVE findings reach --fail-on threshold (implies --validate)")
 	cmd.Flags().IntVar(&opts.MaxTargetMegabytes, "max-target-megabytes", 50, "Skip files larger than this size in MB")
 	cmd.Flags().IntVar(&opts.Threads, "threads", 0, "Parallel scanning workers (0=auto)")
+	cmd.Flags().BoolVar(&opts.NoCache, "no-cache", false, "Rescan every file instead of reusing cached results from <target>/"+scan.DefaultCacheDir)
+	cmd.Flags().BoolVar(&opts.NoGitignore, "no-gitignore", false, "Scan paths excluded by .gitignore (.secrethawkignore still applies)")
+	cmd.Flags().StringVar(&opts.ImageTar, "image-tar", "", "Scan a docker save tarball or OCI image layout directory layer by layer")
+	cmd.Flags().StringVar(&opts.StdinFilename, "stdin-filename", "", "Virtual file name used for policy and findings when scanning stdin")
 
 	return cmd
 }
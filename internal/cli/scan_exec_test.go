@@ -2,10 +2,14 @@ package cli
 
 import (
 	"bytes"
+	"encoding/json"
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
+
+	"github.com/peter941221/secrethawk/internal/model"
 )
 
 func TestScanFailOnReturnsExitError(t *testing.T) {
@@ -51,6 +55,63 @@ func TestScanFailOnActiveDoesNotFailForUnknownOrErrorValidation(t *testing.T) {
 	}
 }
 
+func TestScanRejectsFailOnActiveWithOffline(t *testing.T) {
+	root := NewRootCommand()
+	var out bytes.Buffer
+	root.SetOut(&out)
+	root.SetErr(&out)
+	root.SetArgs([]string{"scan", t.TempDir(), "--offline", "--fail-on-active", "--fail-on", "high"})
+	err := root.Execute()
+	exitErr, ok := err.(*ExitError)
+	if !ok || exitErr.Code != 2 {
+		t.Fatalf("expected usage error, got %v", err)
+	}
+}
+
 func testAWSKey() string {
 	return "AKIA3EXA" + "MPLE7JKXQ4F7"
 }
+
+func TestScanStdinUsesVirtualFilename(t *testing.T) {
+	tmp := t.TempDir()
+	policyPath := filepath.Join(tmp, "policy.yaml")
+	policy := `version: "1"
+scan:
+  exclude_paths:
+    - "fixtures/**"
+severity:
+  block_on: high
+`
+	if err := os.WriteFile(policyPath, []byte(policy), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	run := func(name string) model.FindingReport {
+		root := NewRootCommand()
+		var out bytes.Buffer
+		root.SetOut(&out)
+		root.SetErr(&out)
+		root.SetIn(strings.NewReader(fmt.Sprintf("data:\n  key: %s\n", testAWSKey())))
+		root.SetArgs([]string{"scan", "-", "--stdin-filename", name, "--format", "json", "--policy", policyPath, "--baseline", filepath.Join(tmp, "baseline.json")})
+		if err := root.Execute(); err != nil {
+			t.Fatalf("scan stdin failed: %v output=%s", err, out.String())
+		}
+		var report model.FindingReport
+		if err := json.Unmarshal(out.Bytes(), &report); err != nil {
+			t.Fatalf("parse scan output: %v output=%s", err, out.String())
+		}
+		return report
+	}
+
+	report := run("k8s/secret.yaml")
+	if len(report.Findings) != 1 || report.Findings[0].Location.File != "k8s/secret.yaml" || report.Findings[0].Location.LineStart != 2 {
+		t.Fatalf("unexpected stdin findings: %+v", report.Findings)
+	}
+	if report.Metadata.ScanMode != "stdin" {
+		t.Fatalf("unexpected scan mode: %s", report.Metadata.ScanMode)
+	}
+
+	if excluded := run("fixtures/secret.yaml"); len(excluded.Findings) != 0 {
+		t.Fatalf("expected exclude_paths to apply to stdin filename, got %d findings", len(excluded.Findings))
+	}
+}
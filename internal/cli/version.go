@@ -3,6 +3,7 @@ package cli
 import (
 	"fmt"
 
+	"github.com/peter941221/secrethawk/internal/rules"
 	"github.com/spf13/cobra"
 )
 
@@ -12,6 +13,7 @@ func newVersionCommand() *cobra.Command {
 		Short: "Print version",
 		RunE: func(cmd *cobra.Command, args []string) error {
 			fmt.Fprintln(cmd.OutOrStdout(), BuildVersion)
+			fmt.Fprintf(cmd.OutOrStdout(), "rules %s\n", rules.CatalogVersion)
 			return nil
 		},
 	}
@@ -5,20 +5,52 @@ import (
 	"fmt"
 	"os"
 
+	"github.com/peter941221/secrethawk/internal/severity"
 	"gopkg.in/yaml.v3"
 )
 
 type Policy struct {
-	Version   string         `yaml:"version"`
-	Scan      ScanPolicy     `yaml:"scan"`
-	Allowlist Allowlist      `yaml:"allowlist"`
-	Severity  SeverityPolicy `yaml:"severity"`
+	Version     string            `yaml:"version"`
+	Scan        ScanPolicy        `yaml:"scan"`
+	Allowlist   Allowlist         `yaml:"allowlist"`
+	Severity    SeverityPolicy    `yaml:"severity"`
+	Suppression SuppressionPolicy `yaml:"suppression"`
 }
 
 type ScanPolicy struct {
-	DefaultMode   string   `yaml:"default_mode"`
-	ExcludePaths  []string `yaml:"exclude_paths"`
-	MaxFileSizeKB int      `yaml:"max_file_size_kb"`
+	DefaultMode   string          `yaml:"default_mode"`
+	ExcludePaths  []string        `yaml:"exclude_paths"`
+	MaxFileSizeKB int             `yaml:"max_file_size_kb"`
+	Entropy       EntropyPolicy   `yaml:"entropy"`
+	Terraform     TerraformPolicy `yaml:"terraform"`
+}
+
+// EntropyPolicy tunes the generic high-entropy detector. Hex and base64 tokens
+// are scored separately because hex tops out at 4 bits of entropy per char.
+type EntropyPolicy struct {
+	Hex    EntropyCharset    `yaml:"hex"`
+	Base64 EntropyCharset    `yaml:"base64"`
+	Paths  []EntropyOverride `yaml:"paths"`
+}
+
+type EntropyCharset struct {
+	MinLength int     `yaml:"min_length"`
+	Threshold float64 `yaml:"threshold"`
+}
+
+// EntropyOverride replaces the non-zero charset settings for files matching
+// Pattern. Later matching entries win.
+type EntropyOverride struct {
+	Pattern string         `yaml:"pattern"`
+	Hex     EntropyCharset `yaml:"hex"`
+	Base64  EntropyCharset `yaml:"base64"`
+}
+
+// TerraformPolicy applies to Terraform state and plan JSON files. Severity,
+// when set, replaces the severity of every finding in them, e.g. critical to
+// treat any state committed to the repository as an incident.
+type TerraformPolicy struct {
+	Severity string `yaml:"severity"`
 }
 
 type Allowlist struct {
@@ -47,6 +79,12 @@ type SeverityPolicy struct {
 	BlockOn string `yaml:"block_on"`
 }
 
+// SuppressionPolicy controls `secrethawk:allow` comments in source files.
+type SuppressionPolicy struct {
+	DisableInline          bool     `yaml:"disable_inline"`
+	ForbidInlineSeverities []string `yaml:"forbid_inline_severities"`
+}
+
 func DefaultPolicy() Policy {
 	return Policy{
 		Version: "1",
@@ -54,6 +92,10 @@ func DefaultPolicy() Policy {
 			DefaultMode:   "directory",
 			ExcludePaths:  []string{".git/**", "vendor/**", "node_modules/**"},
 			MaxFileSizeKB: 500,
+			Entropy: EntropyPolicy{
+				Hex:    EntropyCharset{MinLength: 32, Threshold: 3.0},
+				Base64: EntropyCharset{MinLength: 20, Threshold: 4.5},
+			},
 		},
 		Severity: SeverityPolicy{BlockOn: "high"},
 	}
@@ -104,5 +146,42 @@ func ValidatePolicy(path string) error {
 	if policy.Severity.BlockOn == "" {
 		return fmt.Errorf("severity.block_on is required")
 	}
+	if err := validateEntropyCharset("scan.entropy.hex", policy.Scan.Entropy.Hex); err != nil {
+		return err
+	}
+	if err := validateEntropyCharset("scan.entropy.base64", policy.Scan.Entropy.Base64); err != nil {
+		return err
+	}
+	for i, o := range policy.Scan.Entropy.Paths {
+		if o.Pattern == "" {
+			return fmt.Errorf("scan.entropy.paths[%d].pattern is required", i)
+		}
+		if err := validateEntropyCharset(fmt.Sprintf("scan.entropy.paths[%d].hex", i), o.Hex); err != nil {
+			return err
+		}
+		if err := validateEntropyCharset(fmt.Sprintf("scan.entropy.paths[%d].base64", i), o.Base64); err != nil {
+			return err
+		}
+	}
+	if policy.Scan.Terraform.Severity != "" {
+		if _, err := severity.Normalize(policy.Scan.Terraform.Severity); err != nil {
+			return fmt.Errorf("scan.terraform.severity: %w", err)
+		}
+	}
+	for _, level := range policy.Suppression.ForbidInlineSeverities {
+		if _, err := severity.Normalize(level); err != nil {
+			return fmt.Errorf("suppression.forbid_inline_severities: %w", err)
+		}
+	}
+	return nil
+}
+
+func validateEntropyCharset(field string, c EntropyCharset) error {
+	if c.MinLength < 0 {
+		return fmt.Errorf("%s.min_length must not be negative", field)
+	}
+	if c.Threshold < 0 {
+		return fmt.Errorf("%s.threshold must not be negative", field)
+	}
 	return nil
 }
@@ -10,22 +10,33 @@ type FindingReport struct {
 }
 
 type Finding struct {
-	ID          string      `json:"id"`
-	RuleID      string      `json:"rule_id"`
-	RuleName    string      `json:"rule_name"`
-	Severity    string      `json:"severity"`
-	Confidence  string      `json:"confidence"`
-	Category    string      `json:"category"`
-	Location    Location    `json:"location"`
-	Match       Match       `json:"match"`
-	Validation  Validation  `json:"validation"`
-	Remediation Remediation `json:"remediation"`
-	LineHash    string      `json:"-"`
-	RawSecret   string      `json:"-"`
+	ID          string       `json:"id"`
+	RuleID      string       `json:"rule_id"`
+	RuleName    string       `json:"rule_name"`
+	Severity    string       `json:"severity"`
+	Confidence  string       `json:"confidence"`
+	Category    string       `json:"category"`
+	Location    Location     `json:"location"`
+	Match       Match        `json:"match"`
+	Validation  Validation   `json:"validation"`
+	Remediation Remediation  `json:"remediation"`
+	Suppression *Suppression `json:"suppression,omitempty"`
+	Fingerprint string       `json:"fingerprint,omitempty"`
+	// Occurrences lists every place a grouped history finding was seen. The
+	// finding's Location is the earliest one.
+	Occurrences     []Occurrence `json:"occurrences,omitempty"`
+	FirstSeenCommit string       `json:"first_seen_commit,omitempty"`
+	LastSeenCommit  string       `json:"last_seen_commit,omitempty"`
+	LineHash        string       `json:"-"`
+	RawSecret       string       `json:"-"`
 }
 
+// Location is where a finding was seen. Address locates the value inside a
+// parsed file, e.g. a Terraform resource attribute or a notebook cell, and is
+// empty for plain line matches.
 type Location struct {
 	File        string     `json:"file"`
+	Address     string     `json:"address,omitempty"`
 	LineStart   int        `json:"line_start"`
 	LineEnd     int        `json:"line_end"`
 	ColumnStart int        `json:"column_start"`
@@ -34,12 +45,28 @@ type Location struct {
 	Branch      string     `json:"branch"`
 	AuthorEmail string     `json:"author_email"`
 	CommittedAt *time.Time `json:"committed_at"`
+	Layer       string     `json:"layer,omitempty"`
+	Source      string     `json:"source,omitempty"`
+}
+
+// Occurrence is one file/line/commit where a grouped secret appeared.
+type Occurrence struct {
+	File        string     `json:"file"`
+	Address     string     `json:"address,omitempty"`
+	LineStart   int        `json:"line_start"`
+	Commit      string     `json:"commit"`
+	AuthorEmail string     `json:"author_email,omitempty"`
+	CommittedAt *time.Time `json:"committed_at,omitempty"`
+	Source      string     `json:"source,omitempty"`
 }
 
 type Match struct {
 	RawRedacted string  `json:"raw_redacted"`
 	Entropy     float64 `json:"entropy"`
 	Length      int     `json:"length"`
+	// Encoding names the encodings decoded to reach the secret, outermost
+	// first (e.g. "base64" or "base64+url").
+	Encoding string `json:"encoding,omitempty"`
 }
 
 type Validation struct {
@@ -49,6 +76,13 @@ type Validation struct {
 	Details     map[string]any `json:"details"`
 }
 
+// Suppression records why a finding is reported but not enforced.
+type Suppression struct {
+	Kind   string `json:"kind"`
+	Reason string `json:"reason"`
+	Line   int    `json:"line,omitempty"`
+}
+
 type Remediation struct {
 	Status       string     `json:"status"`
 	ActionsTaken []string   `json:"actions_taken"`
@@ -63,8 +97,12 @@ type Metadata struct {
 	ScanTarget       string         `json:"scan_target"`
 	ScanMode         string         `json:"scan_mode"`
 	FilesScanned     int            `json:"files_scanned"`
+	CacheHits        int            `json:"cache_hits,omitempty"`
+	SkippedPaths     map[string]int `json:"skipped_paths,omitempty"`
+	Suppressed       int            `json:"suppressed,omitempty"`
 	DurationMS       int64          `json:"duration_ms"`
 	RulesLoaded      int            `json:"rules_loaded"`
+	RulesVersion     string         `json:"rules_version,omitempty"`
 	PolicyFile       string         `json:"policy_file"`
 	SeverityCounts   map[string]int `json:"severity_counts,omitempty"`
 	ValidationCounts map[string]int `json:"validation_counts,omitempty"`
@@ -0,0 +1,51 @@
+package offline
+
+import (
+	"hash/crc32"
+	"regexp"
+)
+
+// githubChecksumPrefixes are the GitHub token types whose last six
+// characters are a base62 CRC32 of the 30 random characters before them.
+var githubChecksumPrefixes = []string{"ghp_", "gho_", "ghu_", "ghs_", "ghr_"}
+
+var (
+	githubTokenRE       = regexp.MustCompile(`^gh[pousr]_[A-Za-z0-9]{36}$`)
+	githubFineGrainedRE = regexp.MustCompile(`^github_pat_[A-Za-z0-9]{22}_[A-Za-z0-9]{59}$`)
+)
+
+const base62Alphabet = "0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz"
+
+func checkGitHubChecksum(token string) Result {
+	const format = "github-checksummed"
+	if !githubTokenRE.MatchString(token) {
+		return invalid(format, "expected a 3-letter prefix, underscore and 36 alphanumerics")
+	}
+	body, sum := token[4:34], token[34:]
+	if base62CRC32(body) != sum {
+		return invalid(format, "checksum mismatch")
+	}
+	return Result{Format: format, Valid: true, Details: map[string]any{"format": format, "checksum": "valid"}}
+}
+
+// checkGitHubFineGrained checks the layout of fine-grained personal access
+// tokens. Their checksum scheme is not published, so only structure is checked.
+func checkGitHubFineGrained(token string) Result {
+	const format = "github-fine-grained"
+	if !githubFineGrainedRE.MatchString(token) {
+		return invalid(format, "expected github_pat_, 22 alphanumerics, underscore and 59 alphanumerics")
+	}
+	return Result{Format: format, Valid: true, Details: map[string]any{"format": format, "structure": "valid"}}
+}
+
+// base62CRC32 encodes the IEEE CRC32 of s in base62, left-padded to six
+// characters, as GitHub does for token checksums.
+func base62CRC32(s string) string {
+	n := crc32.ChecksumIEEE([]byte(s))
+	out := []byte("000000")
+	for i := len(out) - 1; i >= 0 && n > 0; i-- {
+		out[i] = base62Alphabet[n%62]
+		n /= 62
+	}
+	return string(out)
+}
@@ -0,0 +1,77 @@
+package offline
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+)
+
+func looksLikeJWT(s string) bool {
+	return strings.HasPrefix(s, "eyJ") && strings.Count(s, ".") == 2
+}
+
+// checkJWT decodes the header and claims of a JSON Web Token. The signature
+// cannot be verified without the key, so only the encoding is checked; expiry
+// is judged against now.
+func checkJWT(token string, now time.Time) Result {
+	const format = "jwt"
+	header, claims, err := DecodeJWT(token)
+	if err != nil {
+		return invalid(format, err.Error())
+	}
+	details := map[string]any{"format": format, "structure": "valid"}
+	if alg, ok := header["alg"].(string); ok {
+		details["alg"] = alg
+	}
+	for _, k := range []string{"iss", "sub", "aud", "kid"} {
+		if v, ok := claims[k]; ok {
+			details[k] = v
+		} else if v, ok := header[k]; ok {
+			details[k] = v
+		}
+	}
+	if exp, ok := NumericDate(claims["exp"]); ok {
+		details["exp"] = exp.UTC().Format(time.RFC3339)
+		details["expired"] = exp.Before(now)
+	}
+	if iat, ok := NumericDate(claims["iat"]); ok {
+		details["iat"] = iat.UTC().Format(time.RFC3339)
+	}
+	return Result{Format: format, Valid: true, Details: details}
+}
+
+// DecodeJWT returns the decoded header and claims of a compact JWT.
+func DecodeJWT(token string) (map[string]any, map[string]any, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, nil, errors.New("expected three dot-separated segments")
+	}
+	header := map[string]any{}
+	if err := decodeSegment(parts[0], &header); err != nil {
+		return nil, nil, errors.New("header is not base64url JSON")
+	}
+	claims := map[string]any{}
+	if err := decodeSegment(parts[1], &claims); err != nil {
+		return nil, nil, errors.New("claims are not base64url JSON")
+	}
+	return header, claims, nil
+}
+
+func decodeSegment(seg string, v any) error {
+	data, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(seg, "="))
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
+
+// NumericDate reads a JWT NumericDate claim such as exp or iat.
+func NumericDate(v any) (time.Time, bool) {
+	f, ok := v.(float64)
+	if !ok {
+		return time.Time{}, false
+	}
+	return time.Unix(int64(f), 0), true
+}
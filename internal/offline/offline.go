@@ -0,0 +1,70 @@
+// Package offline checks the structure and embedded checksums of token
+// formats that can be verified without contacting the issuing provider.
+package offline
+
+import (
+	"strings"
+	"time"
+)
+
+// StatusInvalidFormat is the validation status for tokens that have a known
+// prefix but fail their structure or checksum check. They are usually
+// placeholders, test fixtures or truncated copies.
+const StatusInvalidFormat = "invalid-format"
+
+// Result is the outcome of an offline check.
+type Result struct {
+	// Format names the recognised token format, e.g. "github-classic".
+	Format string
+	// Valid is false when the token fails its structure or checksum check.
+	Valid bool
+	// Details holds what could be read from the token, such as the checksum
+	// outcome or decoded claims.
+	Details map[string]any
+}
+
+type checker struct {
+	match func(string) bool
+	check func(string, time.Time) Result
+}
+
+var checkers = []checker{
+	{hasPrefix(githubChecksumPrefixes...), timeless(checkGitHubChecksum)},
+	{hasPrefix("github_pat_"), timeless(checkGitHubFineGrained)},
+	{hasPrefix("npm_"), timeless(checkNPM)},
+	{hasPrefix("pypi-"), timeless(checkPyPI)},
+	{looksLikeJWT, checkJWT},
+}
+
+// Check runs the offline check for secret's format, judging time-bound
+// claims such as JWT expiry against now. ok is false when no check applies
+// to it.
+func Check(secret string, now time.Time) (Result, bool) {
+	for _, c := range checkers {
+		if c.match(secret) {
+			return c.check(secret, now), true
+		}
+	}
+	return Result{}, false
+}
+
+func timeless(check func(string) Result) func(string, time.Time) Result {
+	return func(s string, _ time.Time) Result {
+		return check(s)
+	}
+}
+
+func hasPrefix(prefixes ...string) func(string) bool {
+	return func(s string) bool {
+		for _, p := range prefixes {
+			if strings.HasPrefix(s, p) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+func invalid(format string, reason string) Result {
+	return Result{Format: format, Details: map[string]any{"format": format, "reason": reason}}
+}
@@ -0,0 +1,65 @@
+package offline
+
+import (
+	"encoding/base64"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCheckGitHubChecksum(t *testing.T) {
+	valid := "ghp_" + "aB3dE5fG7hJ9kL1mN3pQ5rS7tV9wX1" + "37Bh6K"
+	res, ok := Check(valid, time.Now())
+	if !ok || !res.Valid || res.Details["checksum"] != "valid" {
+		t.Fatalf("expected valid checksum, got %+v ok=%v", res, ok)
+	}
+
+	// Hand-typed placeholders keep the shape but not the checksum.
+	res, ok = Check("ghp_"+strings.Repeat("A", 36), time.Now())
+	if !ok || res.Valid || res.Details["reason"] != "checksum mismatch" {
+		t.Fatalf("expected checksum mismatch, got %+v ok=%v", res, ok)
+	}
+}
+
+func TestCheckPackageRegistryTokens(t *testing.T) {
+	if res, _ := Check("npm_"+strings.Repeat("a1", 18), time.Now()); !res.Valid {
+		t.Fatalf("expected valid npm structure, got %+v", res)
+	}
+	if res, _ := Check("npm_short", time.Now()); res.Valid {
+		t.Fatalf("expected invalid npm token, got %+v", res)
+	}
+
+	macaroon := append([]byte{0x02, 0x01, byte(len("pypi.org"))}, "pypi.org\x02\x01x"...)
+	res, ok := Check("pypi-"+base64.RawURLEncoding.EncodeToString(macaroon), time.Now())
+	if !ok || !res.Valid || res.Details["location"] != "pypi.org" {
+		t.Fatalf("expected valid pypi macaroon, got %+v ok=%v", res, ok)
+	}
+	if res, _ := Check("pypi-"+base64.RawURLEncoding.EncodeToString([]byte("not a macaroon")), time.Now()); res.Valid {
+		t.Fatalf("expected invalid pypi token, got %+v", res)
+	}
+}
+
+func TestCheckJWTRecordsClaims(t *testing.T) {
+	enc := base64.RawURLEncoding.EncodeToString
+	token := enc([]byte(`{"alg":"HS256","typ":"JWT"}`)) + "." + enc([]byte(`{"iss":"auth.example.com","sub":"svc","exp":1000}`)) + ".sig"
+	res, ok := Check(token, time.Unix(2000, 0))
+	if !ok || !res.Valid {
+		t.Fatalf("expected valid jwt, got %+v ok=%v", res, ok)
+	}
+	if res.Details["alg"] != "HS256" || res.Details["iss"] != "auth.example.com" || res.Details["expired"] != true {
+		t.Fatalf("unexpected jwt details: %+v", res.Details)
+	}
+	if res, _ := Check(token, time.Unix(500, 0)); res.Details["expired"] != false {
+		t.Fatalf("expected jwt unexpired at its scan time, got %+v", res.Details)
+	}
+
+	if res, _ := Check("eyJub3Q.anNvbg.sig", time.Now()); res.Valid {
+		t.Fatalf("expected invalid jwt, got %+v", res)
+	}
+}
+
+func TestCheckIgnoresUnknownFormats(t *testing.T) {
+	if _, ok := Check("AKIA"+strings.Repeat("B", 16), time.Now()); ok {
+		t.Fatal("expected no offline check for AWS keys")
+	}
+}
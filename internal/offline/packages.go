@@ -0,0 +1,50 @@
+package offline
+
+import (
+	"bytes"
+	"encoding/base64"
+	"regexp"
+	"strings"
+)
+
+var npmTokenRE = regexp.MustCompile(`^npm_[A-Za-z0-9]{36}$`)
+
+// pypiLocations are the macaroon locations PyPI and TestPyPI issue tokens for.
+var pypiLocations = []string{"pypi.org", "test.pypi.org"}
+
+func checkNPM(token string) Result {
+	const format = "npm"
+	if !npmTokenRE.MatchString(token) {
+		return invalid(format, "expected npm_ and 36 alphanumerics")
+	}
+	return Result{Format: format, Valid: true, Details: map[string]any{"format": format, "structure": "valid"}}
+}
+
+// checkPyPI decodes the macaroon after "pypi-". PyPI issues version 2 binary
+// macaroons whose first field is the location they are valid for.
+func checkPyPI(token string) Result {
+	const format = "pypi"
+	raw, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(strings.TrimPrefix(token, "pypi-"), "="))
+	if err != nil {
+		return invalid(format, "macaroon is not base64url")
+	}
+	// Version byte, then a location field: type 1, length, value.
+	if len(raw) < 3 || raw[0] != 0x02 || raw[1] != 0x01 {
+		return invalid(format, "not a version 2 macaroon")
+	}
+	n := int(raw[2])
+	if len(raw) < 3+n {
+		return invalid(format, "truncated macaroon")
+	}
+	location := string(raw[3 : 3+n])
+	for _, l := range pypiLocations {
+		if location == l {
+			details := map[string]any{"format": format, "structure": "valid", "location": location}
+			if bytes.Contains(raw, []byte(`"permissions"`)) {
+				details["scoped"] = true
+			}
+			return Result{Format: format, Valid: true, Details: details}
+		}
+	}
+	return invalid(format, "unexpected macaroon location "+location)
+}
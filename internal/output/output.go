@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"sort"
 	"strings"
 
 	"github.com/peter941221/secrethawk/internal/model"
@@ -18,23 +19,74 @@ func Write(report model.FindingReport, format string, w io.Writer) error {
 		return writeJSON(report, w)
 	case "sarif":
 		return writeSARIF(report, w)
+	case "ndjson":
+		return writeNDJSON(report, w)
 	default:
 		return fmt.Errorf("unsupported output format: %s", format)
 	}
 }
 
+// LocationString formats a finding location as file:line, followed by the
+// address inside the file when there is one.
+func LocationString(loc model.Location) string {
+	s := fmt.Sprintf("%s:%d", loc.File, loc.LineStart)
+	if loc.Address != "" {
+		s += " (" + loc.Address + ")"
+	}
+	return s
+}
+
+func shortSHA(sha string) string {
+	if len(sha) > 12 {
+		return sha[:12]
+	}
+	return sha
+}
+
 func writeHuman(report model.FindingReport, w io.Writer) {
 	fmt.Fprintln(w, "SecretHawk scan result")
 	fmt.Fprintln(w, "--------------------")
 	for _, f := range report.Findings {
 		fmt.Fprintf(w, "%s %s\n", severityBadge(f.Severity), strings.ToUpper(f.RuleName))
-		fmt.Fprintf(w, "  File:   %s:%d\n", f.Location.File, f.Location.LineStart)
+		fmt.Fprintf(w, "  File:   %s\n", LocationString(f.Location))
+		if f.Location.Commit != nil {
+			fmt.Fprintf(w, "  Commit: %s", shortSHA(*f.Location.Commit))
+			if f.Location.AuthorEmail != "" {
+				fmt.Fprintf(w, " by %s", f.Location.AuthorEmail)
+			}
+			if f.Location.Branch != "" {
+				fmt.Fprintf(w, " on %s", f.Location.Branch)
+			}
+			fmt.Fprintln(w)
+		}
+		if len(f.Occurrences) > 1 {
+			fmt.Fprintf(w, "  Seen:   %d times (first %s, last %s)\n", len(f.Occurrences), shortSHA(f.FirstSeenCommit), shortSHA(f.LastSeenCommit))
+		}
+		if f.Location.Layer != "" {
+			fmt.Fprintf(w, "  Layer:  %s\n", f.Location.Layer)
+		}
+		if f.Location.Source != "" {
+			fmt.Fprintf(w, "  Source: %s\n", f.Location.Source)
+		}
 		fmt.Fprintf(w, "  Match:  %s\n", f.Match.RawRedacted)
+		if keyPath, ok := f.Validation.Details["key_path"].(string); ok && keyPath != "" {
+			fmt.Fprintf(w, "  Key:    %s\n", keyPath)
+		}
+		if f.Match.Encoding != "" {
+			fmt.Fprintf(w, "  Encoding: %s\n", f.Match.Encoding)
+		}
 		fmt.Fprintf(w, "  Confidence: %s\n", strings.ToUpper(f.Confidence))
 		fmt.Fprintf(w, "  Status: %s\n", strings.ToUpper(defaultValidationStatus(f.Validation.Status)))
+		if f.Suppression != nil {
+			fmt.Fprintf(w, "  Suppressed: %s (%s)\n", f.Suppression.Reason, f.Suppression.Kind)
+		}
 		fmt.Fprintln(w)
 	}
-	fmt.Fprintf(w, "Summary: %d findings\n", len(report.Findings))
+	if report.Metadata.Suppressed > 0 {
+		fmt.Fprintf(w, "Summary: %d findings (%d suppressed)\n", len(report.Findings), report.Metadata.Suppressed)
+	} else {
+		fmt.Fprintf(w, "Summary: %d findings\n", len(report.Findings))
+	}
 	if len(report.Metadata.SeverityCounts) > 0 {
 		fmt.Fprintf(w, "  Severity: critical=%d high=%d medium=%d low=%d\n",
 			report.Metadata.SeverityCounts["critical"],
@@ -44,13 +96,26 @@ func writeHuman(report model.FindingReport, w io.Writer) {
 		)
 	}
 	if len(report.Metadata.ValidationCounts) > 0 {
-		fmt.Fprintf(w, "  Validation: active=%d inactive=%d unknown=%d error=%d\n",
+		fmt.Fprintf(w, "  Validation: active=%d inactive=%d unknown=%d error=%d invalid-format=%d\n",
 			report.Metadata.ValidationCounts["active"],
 			report.Metadata.ValidationCounts["inactive"],
 			report.Metadata.ValidationCounts["unknown"],
 			report.Metadata.ValidationCounts["error"],
+			report.Metadata.ValidationCounts["invalid-format"],
 		)
 	}
+	if len(report.Metadata.SkippedPaths) > 0 {
+		sources := make([]string, 0, len(report.Metadata.SkippedPaths))
+		for source := range report.Metadata.SkippedPaths {
+			sources = append(sources, source)
+		}
+		sort.Strings(sources)
+		parts := make([]string, 0, len(sources))
+		for _, source := range sources {
+			parts = append(parts, fmt.Sprintf("%s=%d", source, report.Metadata.SkippedPaths[source]))
+		}
+		fmt.Fprintf(w, "  Skipped: %s\n", strings.Join(parts, " "))
+	}
 }
 
 func writeJSON(report model.FindingReport, w io.Writer) error {
@@ -59,6 +124,37 @@ func writeJSON(report model.FindingReport, w io.Writer) error {
 	return enc.Encode(report)
 }
 
+// NDJSONWriter writes one finding per line as findings arrive and ends the
+// stream with a {"metadata": ...} record, so partial output stays usable if a
+// long scan is interrupted.
+type NDJSONWriter struct {
+	enc *json.Encoder
+}
+
+func NewNDJSONWriter(w io.Writer) *NDJSONWriter {
+	return &NDJSONWriter{enc: json.NewEncoder(w)}
+}
+
+func (n *NDJSONWriter) WriteFinding(f model.Finding) error {
+	return n.enc.Encode(f)
+}
+
+func (n *NDJSONWriter) WriteMetadata(m model.Metadata) error {
+	return n.enc.Encode(struct {
+		Metadata model.Metadata `json:"metadata"`
+	}{m})
+}
+
+func writeNDJSON(report model.FindingReport, w io.Writer) error {
+	n := NewNDJSONWriter(w)
+	for _, f := range report.Findings {
+		if err := n.WriteFinding(f); err != nil {
+			return err
+		}
+	}
+	return n.WriteMetadata(report.Metadata)
+}
+
 func writeSARIF(report model.FindingReport, w io.Writer) error {
 	type artifactLocation struct {
 		URI string `json:"uri"`
@@ -73,35 +169,53 @@ func writeSARIF(report model.FindingReport, w io.Writer) error {
 		ArtifactLocation artifactLocation `json:"artifactLocation"`
 		Region           region           `json:"region"`
 	}
+	type logicalLocation struct {
+		FullyQualifiedName string `json:"fullyQualifiedName"`
+	}
 	type location struct {
-		PhysicalLocation physicalLocation `json:"physicalLocation"`
+		PhysicalLocation physicalLocation  `json:"physicalLocation"`
+		LogicalLocations []logicalLocation `json:"logicalLocations,omitempty"`
+	}
+	type suppression struct {
+		Kind          string `json:"kind"`
+		Justification string `json:"justification"`
 	}
 	type result struct {
-		RuleID    string     `json:"ruleId"`
-		Level     string     `json:"level"`
-		Message   any        `json:"message"`
-		Locations []location `json:"locations"`
+		RuleID       string        `json:"ruleId"`
+		Level        string        `json:"level"`
+		Message      any           `json:"message"`
+		Locations    []location    `json:"locations"`
+		Suppressions []suppression `json:"suppressions,omitempty"`
 	}
 
 	results := make([]result, 0, len(report.Findings))
 	for _, f := range report.Findings {
+		var suppressions []suppression
+		if f.Suppression != nil {
+			suppressions = []suppression{{Kind: "inSource", Justification: f.Suppression.Reason}}
+		}
+		loc := location{
+			PhysicalLocation: physicalLocation{
+				ArtifactLocation: artifactLocation{URI: f.Location.File},
+				Region: region{
+					StartLine:   f.Location.LineStart,
+					EndLine:     f.Location.LineEnd,
+					StartColumn: f.Location.ColumnStart,
+					EndColumn:   f.Location.ColumnEnd,
+				},
+			},
+		}
+		if f.Location.Address != "" {
+			loc.LogicalLocations = []logicalLocation{{FullyQualifiedName: f.Location.Address}}
+		}
 		results = append(results, result{
 			RuleID: f.RuleID,
 			Level:  sarifLevel(f.Severity),
 			Message: map[string]string{
 				"text": fmt.Sprintf("%s detected: %s", f.RuleName, f.Match.RawRedacted),
 			},
-			Locations: []location{{
-				PhysicalLocation: physicalLocation{
-					ArtifactLocation: artifactLocation{URI: f.Location.File},
-					Region: region{
-						StartLine:   f.Location.LineStart,
-						EndLine:     f.Location.LineEnd,
-						StartColumn: f.Location.ColumnStart,
-						EndColumn:   f.Location.ColumnEnd,
-					},
-				},
-			}},
+			Locations:    []location{loc},
+			Suppressions: suppressions,
 		})
 	}
 
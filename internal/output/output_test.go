@@ -65,3 +65,32 @@ func TestWriteHumanIncludesSummaryCounts(t *testing.T) {
 		t.Fatalf("missing validation summary: %s", out)
 	}
 }
+
+func TestWriteNDJSONEndsWithMetadata(t *testing.T) {
+	report := model.FindingReport{
+		Findings: []model.Finding{
+			{RuleID: "aws-access-key-id", Location: model.Location{File: "a.py", LineStart: 1}},
+			{RuleID: "github-pat-classic", Location: model.Location{File: "b.py", LineStart: 2}},
+		},
+		Metadata: model.Metadata{Version: "test", ScanMode: "directory"},
+	}
+
+	var buf bytes.Buffer
+	if err := Write(report, "ndjson", &buf); err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("expected 3 records, got %d: %s", len(lines), buf.String())
+	}
+	var f model.Finding
+	if err := json.Unmarshal([]byte(lines[1]), &f); err != nil || f.RuleID != "github-pat-classic" {
+		t.Fatalf("unexpected finding record %q: %v", lines[1], err)
+	}
+	var last struct {
+		Metadata model.Metadata `json:"metadata"`
+	}
+	if err := json.Unmarshal([]byte(lines[2]), &last); err != nil || last.Metadata.ScanMode != "directory" {
+		t.Fatalf("unexpected metadata record %q: %v", lines[2], err)
+	}
+}
@@ -0,0 +1,168 @@
+package patch
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/peter941221/secrethawk/internal/model"
+	"github.com/peter941221/secrethawk/internal/scan"
+)
+
+// patchNotebook replaces secrets found in the source cells of a Jupyter
+// notebook. The notebook is decoded, edited cell by cell and re-encoded in
+// nbformat's own layout (sorted keys, one-space indent), so a replacement
+// containing quotes cannot corrupt the JSON. Outputs are left untouched.
+func patchNotebook(file string, findings []model.Finding, opts Options) ([]Change, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	var nb map[string]any
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	if err := dec.Decode(&nb); err != nil {
+		return nil, fmt.Errorf("parse notebook %s: %w", file, err)
+	}
+	cells, _ := nb["cells"].([]any)
+	ext := notebookExt(nb)
+
+	changes := []Change{}
+	bySecret := map[string]int{}
+	seen := map[string]struct{}{}
+	for _, f := range findings {
+		idx, ok := scan.NotebookSourceCell(f.Location)
+		if !ok || idx >= len(cells) {
+			continue
+		}
+		key := fmt.Sprintf("%d|%s", idx, f.RawSecret)
+		if _, ok := seen[key]; ok {
+			continue
+		}
+		seen[key] = struct{}{}
+		cell, ok := cells[idx].(map[string]any)
+		if !ok {
+			continue
+		}
+		source, isList := cellSource(cell["source"])
+		varName := envVarName(f.RuleID, opts.VarPrefix)
+		repl := replacementByFile("notebook"+ext, varName, opts.ReplaceWith)
+		source, count := replaceInSource(source, f.RawSecret, repl, ext, opts.ReplaceWith)
+		if count == 0 {
+			continue
+		}
+		cell["source"] = setCellSource(source, isList)
+		if i, ok := bySecret[f.RawSecret]; ok {
+			changes[i].Count += count
+			continue
+		}
+		bySecret[f.RawSecret] = len(changes)
+		changes = append(changes, Change{
+			File:        file,
+			RuleID:      f.RuleID,
+			VarName:     varName,
+			Replacement: repl,
+			Count:       count,
+		})
+	}
+
+	if len(changes) > 0 && !opts.DryRun {
+		var buf bytes.Buffer
+		enc := json.NewEncoder(&buf)
+		enc.SetEscapeHTML(false)
+		enc.SetIndent("", " ")
+		if err := enc.Encode(nb); err != nil {
+			return nil, err
+		}
+		if err := os.WriteFile(file, buf.Bytes(), 0o644); err != nil {
+			return nil, err
+		}
+	}
+	return changes, nil
+}
+
+// literalQuotes lists the string delimiters of each notebook language whose
+// replacements are code expressions, longest first.
+var literalQuotes = map[string][]string{
+	".py": {`"""`, `'''`, `"`, `'`},
+	".js": {`"`, `'`, "`"},
+	".ts": {`"`, `'`, "`"},
+	".go": {`"`, "`"},
+}
+
+// replaceInSource replaces secret in cell code with repl. A string literal
+// holding exactly the secret is replaced including its quotes, so an
+// expression such as os.environ["KEY"] leaves valid code; other occurrences
+// are replaced in place.
+func replaceInSource(source string, secret string, repl string, ext string, replaceWith string) (string, int) {
+	count := 0
+	if replaceWith != "placeholder" {
+		for _, q := range literalQuotes[ext] {
+			literal := q + secret + q
+			count += strings.Count(source, literal)
+			source = strings.ReplaceAll(source, literal, repl)
+		}
+	}
+	count += strings.Count(source, secret)
+	return strings.ReplaceAll(source, secret, repl), count
+}
+
+// cellSource joins a cell source, which nbformat allows as a string or as a
+// list of lines, and reports which form it was in.
+func cellSource(v any) (string, bool) {
+	switch s := v.(type) {
+	case string:
+		return s, false
+	case []any:
+		var b strings.Builder
+		for _, line := range s {
+			if l, ok := line.(string); ok {
+				b.WriteString(l)
+			}
+		}
+		return b.String(), true
+	}
+	return "", false
+}
+
+func setCellSource(source string, asList bool) any {
+	if !asList {
+		return source
+	}
+	lines := make([]any, 0)
+	for _, l := range strings.SplitAfter(source, "\n") {
+		if l != "" {
+			lines = append(lines, l)
+		}
+	}
+	return lines
+}
+
+// notebookExt maps the notebook's kernel language to the file extension whose
+// replacement syntax applies to its cells.
+func notebookExt(nb map[string]any) string {
+	lang := ""
+	if meta, ok := nb["metadata"].(map[string]any); ok {
+		if ks, ok := meta["kernelspec"].(map[string]any); ok {
+			lang, _ = ks["language"].(string)
+		}
+		if info, ok := meta["language_info"].(map[string]any); ok && lang == "" {
+			lang, _ = info["name"].(string)
+		}
+	}
+	switch strings.ToLower(lang) {
+	case "", "python":
+		return ".py"
+	case "javascript":
+		return ".js"
+	case "typescript":
+		return ".ts"
+	case "go":
+		return ".go"
+	case "bash":
+		return ".sh"
+	}
+	return ""
+}
@@ -67,7 +67,7 @@ func Apply(ctx context.Context, opts Options) (Result, error) {
 
 	grouped := map[string][]model.Finding{}
 	for _, f := range scanResult.Report.Findings {
-		if f.RawSecret == "" {
+		if f.RawSecret == "" || f.Suppression != nil {
 			continue
 		}
 		if f.Location.Commit != nil {
@@ -76,6 +76,16 @@ func Apply(ctx context.Context, opts Options) (Result, error) {
 		if f.RuleID == "generic-high-entropy" {
 			continue
 		}
+		if scan.IsArchiveEntry(f.Location.File) {
+			continue
+		}
+		if _, ok := scan.NotebookSourceCell(f.Location); ok {
+			grouped[f.Location.File] = append(grouped[f.Location.File], f)
+			continue
+		}
+		if f.Location.Address != "" {
+			continue
+		}
 		if !isPatchableCodeFile(f.Location.File) {
 			continue
 		}
@@ -86,6 +96,19 @@ func Apply(ctx context.Context, opts Options) (Result, error) {
 	varsToAdd := map[string]struct{}{}
 
 	for file, findings := range grouped {
+		if strings.EqualFold(filepath.Ext(file), ".ipynb") {
+			nbChanges, err := patchNotebook(file, findings, opts)
+			if err != nil {
+				return Result{}, err
+			}
+			for _, c := range nbChanges {
+				changes = append(changes, c)
+				if opts.ReplaceWith == "env" {
+					varsToAdd[c.VarName] = struct{}{}
+				}
+			}
+			continue
+		}
 		data, err := os.ReadFile(file)
 		if err != nil {
 			return Result{}, err
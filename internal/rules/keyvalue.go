@@ -0,0 +1,66 @@
+package rules
+
+import (
+	"regexp"
+	"strings"
+)
+
+// defaultMinValueLength is used by keyvalue rules that do not set
+// detection.min_value_length.
+const defaultMinValueLength = 8
+
+var referenceRE = regexp.MustCompile(`^(?:\$\{[^}]*\}|\$[A-Za-z_][A-Za-z0-9_]*|\{\{.*\}\}|%\([^)]*\)s|#\{[^}]*\}|<[^>]*>|ENC\(.*\)|vault:.*|ref\+.*)$`)
+
+var placeholderWords = map[string]struct{}{
+	"changeme": {}, "password": {}, "passwd": {}, "secret": {}, "example": {},
+	"placeholder": {}, "redacted": {}, "todo": {}, "tbd": {}, "none": {},
+	"null": {}, "nil": {}, "empty": {}, "dummy": {}, "test": {}, "sample": {},
+	"notset": {}, "default": {}, "yourpassword": {}, "yoursecret": {},
+	"yourtoken": {}, "yourapikey": {}, "insertpasswordhere": {},
+}
+
+// MatchKeyValue reports whether a keyvalue rule fires for a parsed key path and
+// value: the key must match detection.key_pattern and the value must look like
+// a real credential rather than a placeholder or reference.
+func MatchKeyValue(r Rule, key string, value string) bool {
+	if r.KeyRegex == nil || !r.KeyRegex.MatchString(key) {
+		return false
+	}
+	value = strings.TrimSpace(value)
+	minLen := r.Detection.MinValueLength
+	if minLen <= 0 {
+		minLen = defaultMinValueLength
+	}
+	if len(value) < minLen || strings.ContainsAny(value, "\n\r") || IsPlaceholder(value) {
+		return false
+	}
+	if r.Regex != nil && !r.Regex.MatchString(value) {
+		return false
+	}
+	return MatchContext(r, value)
+}
+
+// IsPlaceholder reports whether value is an obvious stand-in such as
+// "changeme", "xxxxxxxx", "<password>" or a ${VAR} style reference.
+func IsPlaceholder(value string) bool {
+	v := strings.TrimSpace(value)
+	if v == "" || referenceRE.MatchString(v) {
+		return true
+	}
+	if strings.Count(v, v[:1]) == len(v) {
+		return true
+	}
+	norm := strings.Map(func(r rune) rune {
+		if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') {
+			return r
+		}
+		if r >= 'A' && r <= 'Z' {
+			return r + ('a' - 'A')
+		}
+		return -1
+	}, v)
+	if _, ok := placeholderWords[norm]; ok {
+		return true
+	}
+	return strings.HasPrefix(norm, "your") || strings.HasPrefix(norm, "xxxx") || strings.HasPrefix(norm, "example")
+}
@@ -0,0 +1,106 @@
+package rules
+
+import "strings"
+
+// KeywordIndex is an Aho-Corasick automaton over every rule's
+// detection.keywords. It finds, in one pass over a line, which rules are
+// worth evaluating. Matching is ASCII case-insensitive.
+type KeywordIndex struct {
+	next    [][256]int32
+	outputs [][]int
+	// always marks rules without keywords; they run on every line.
+	always []bool
+}
+
+func NewKeywordIndex(rs []Rule) *KeywordIndex {
+	idx := &KeywordIndex{
+		next:    [][256]int32{{}},
+		outputs: [][]int{nil},
+		always:  make([]bool, len(rs)),
+	}
+	for i, r := range rs {
+		if len(r.Detection.Keywords) == 0 {
+			idx.always[i] = true
+			continue
+		}
+		for _, kw := range r.Detection.Keywords {
+			kw = strings.ToLower(kw)
+			if kw == "" {
+				continue
+			}
+			node := int32(0)
+			for j := 0; j < len(kw); j++ {
+				c := kw[j]
+				if idx.next[node][c] == 0 {
+					idx.next = append(idx.next, [256]int32{})
+					idx.outputs = append(idx.outputs, nil)
+					idx.next[node][c] = int32(len(idx.next) - 1)
+				}
+				node = idx.next[node][c]
+			}
+			idx.outputs[node] = append(idx.outputs[node], i)
+		}
+	}
+	idx.build()
+	return idx
+}
+
+// build turns the trie into a full transition table by following failure
+// links breadth-first, merging outputs along the way.
+func (k *KeywordIndex) build() {
+	fail := make([]int32, len(k.next))
+	queue := make([]int32, 0, len(k.next))
+	for c := 0; c < 256; c++ {
+		if n := k.next[0][c]; n != 0 {
+			queue = append(queue, n)
+		}
+	}
+	for len(queue) > 0 {
+		node := queue[0]
+		queue = queue[1:]
+		k.outputs[node] = append(k.outputs[node], k.outputs[fail[node]]...)
+		for c := 0; c < 256; c++ {
+			child := k.next[node][c]
+			if child == 0 {
+				k.next[node][c] = k.next[fail[node]][c]
+				continue
+			}
+			fail[child] = k.next[fail[node]][c]
+			queue = append(queue, child)
+		}
+	}
+}
+
+// Candidates reports, per rule index, whether the rule should be evaluated
+// against text.
+func (k *KeywordIndex) Candidates(text string) []bool {
+	out := make([]bool, len(k.always))
+	copy(out, k.always)
+	node := int32(0)
+	for i := 0; i < len(text); i++ {
+		c := text[i]
+		if c >= 'A' && c <= 'Z' {
+			c += 'a' - 'A'
+		}
+		node = k.next[node][c]
+		for _, r := range k.outputs[node] {
+			out[r] = true
+		}
+	}
+	return out
+}
+
+// HasKeyword reports whether input contains one of the rule's keywords. Rules
+// without keywords always pass.
+func HasKeyword(r Rule, input string) bool {
+	if len(r.Detection.Keywords) == 0 {
+		return true
+	}
+	lower := strings.ToLower(normalizeTestInput(input))
+	for _, kw := range r.Detection.Keywords {
+		if kw != "" && strings.Contains(lower, strings.ToLower(kw)) {
+			return true
+		}
+	}
+	return false
+}
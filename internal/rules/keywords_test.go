@@ -0,0 +1,50 @@
+package rules
+
+import "testing"
+
+func TestKeywordIndexCandidates(t *testing.T) {
+	rs := []Rule{
+		{ID: "aws", Detection: DetectionSpec{Keywords: []string{"AKIA"}}},
+		{ID: "slack", Detection: DetectionSpec{Keywords: []string{"xoxb-", "xoxp-"}}},
+		{ID: "any"},
+	}
+	idx := NewKeywordIndex(rs)
+
+	tests := []struct {
+		line string
+		want []bool
+	}{
+		{"key = akia1234", []bool{true, false, true}},
+		{"t=XOXP-123 and AKIA", []bool{true, true, true}},
+		{"xoxa-nothing", []bool{false, false, true}},
+		{"", []bool{false, false, true}},
+	}
+	for _, tc := range tests {
+		got := idx.Candidates(tc.line)
+		for i := range tc.want {
+			if got[i] != tc.want[i] {
+				t.Fatalf("line %q rule %s: got %v want %v", tc.line, rs[i].ID, got[i], tc.want[i])
+			}
+		}
+	}
+}
+
+func TestBundledPositiveCasesContainKeywords(t *testing.T) {
+	rs, err := Load("../../rules", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, r := range rs {
+		if r.Type != TypeRegex {
+			continue
+		}
+		if len(r.Detection.Keywords) == 0 {
+			t.Fatalf("rule %s declares no keywords", r.ID)
+		}
+		for _, tc := range r.Tests.Positive {
+			if !HasKeyword(r, tc.Input) {
+				t.Fatalf("rule=%s positive case lacks keyword: %q", r.ID, tc.Input)
+			}
+		}
+	}
+}
@@ -2,12 +2,17 @@ package rules
 
 import (
 	"fmt"
+	"io/fs"
 	"os"
 	"path/filepath"
 	"regexp"
 	"sort"
+	"strconv"
 	"strings"
+	"time"
 
+	"github.com/peter941221/secrethawk/internal/severity"
+	catalog "github.com/peter941221/secrethawk/rules"
 	"gopkg.in/yaml.v3"
 )
 
@@ -15,8 +20,25 @@ type File struct {
 	Rules []Rule `yaml:"rules"`
 }
 
+// Rule types. Regex rules match detection.regex line by line; the entropy
+// rule is the generic high-entropy detector tuned through policy; keyvalue
+// rules match key paths parsed from structured config files; the terraform
+// rule reports attributes Terraform marks sensitive in state and plan files;
+// the kubernetes rule reports Secret values no other rule matched.
+const (
+	TypeRegex      = "regex"
+	TypeEntropy    = "entropy"
+	TypeKeyValue   = "keyvalue"
+	TypeJWT        = "jwt"
+	TypeURI        = "uri"
+	TypeTerraform  = "terraform"
+	TypeKubernetes = "kubernetes"
+)
+
 type Rule struct {
 	ID          string          `yaml:"id"`
+	Type        string          `yaml:"type"`
+	Disabled    bool            `yaml:"disabled"`
 	Name        string          `yaml:"name"`
 	Severity    string          `yaml:"severity"`
 	Category    string          `yaml:"category"`
@@ -27,19 +49,59 @@ type Rule struct {
 	Tests       RuleTests       `yaml:"tests"`
 
 	Regex        *regexp.Regexp   `yaml:"-"`
-	MustMatch    []*regexp.Regexp `yaml:"-"`
-	MustNotMatch []*regexp.Regexp `yaml:"-"`
+	KeyRegex     *regexp.Regexp   `yaml:"-"`
+	MustMatch    []ContextPattern `yaml:"-"`
+	MustNotMatch []ContextPattern `yaml:"-"`
+	// JWTLongLivedAfter is the parsed detection.jwt.long_lived_after.
+	JWTLongLivedAfter time.Duration `yaml:"-"`
+}
+
+// ContextPattern is a compiled must_match/must_not_match guard. Lines is the
+// number of lines above and below the match the pattern may look at.
+type ContextPattern struct {
+	Regex *regexp.Regexp
+	Lines int
 }
 
 type DetectionSpec struct {
 	Regex        string         `yaml:"regex"`
+	Multiline    bool           `yaml:"multiline"`
+	Keywords     []string       `yaml:"keywords"`
 	MustMatch    []RegexWrapper `yaml:"must_match"`
 	MustNotMatch []RegexWrapper `yaml:"must_not_match"`
+	// KeyPattern and MinValueLength apply to keyvalue rules. For those rules
+	// Regex is optional and, when set, must match the value.
+	KeyPattern     string `yaml:"key_pattern"`
+	MinValueLength int    `yaml:"min_value_length"`
+	// JWT adjusts the severity of jwt rules from the decoded claims.
+	JWT JWTSpec `yaml:"jwt"`
+	// Schemes limits uri rules to these URI schemes, e.g. postgres or
+	// mongodb+srv. JDBC URLs are matched by their inner scheme.
+	Schemes []string `yaml:"schemes"`
+}
+
+// JWTSpec sets the severity a jwt rule reports for tokens in each state. An
+// empty severity keeps the rule's own severity.
+type JWTSpec struct {
+	ExpiredSeverity   string `yaml:"expired_severity"`
+	AlgNoneSeverity   string `yaml:"alg_none_severity"`
+	NoExpirySeverity  string `yaml:"no_expiry_severity"`
+	LongLivedSeverity string `yaml:"long_lived_severity"`
+	// LongLivedAfter is the exp-iat lifetime above which a token counts as
+	// long-lived, as a Go duration or a number of days such as "30d".
+	LongLivedAfter string `yaml:"long_lived_after"`
 }
 
+// jwtPattern matches compact JWTs whose header and claims are JSON objects.
+// The signature may be empty for alg "none".
+const jwtPattern = `(?:^|[^A-Za-z0-9_.-])(eyJ[A-Za-z0-9_-]{8,}\.eyJ[A-Za-z0-9_-]{8,}\.[A-Za-z0-9_-]*)`
+
 type RegexWrapper struct {
 	Regex        string `yaml:"regex"`
 	ContextRegex string `yaml:"context_regex"`
+	// ContextLines widens the guard to N lines above and below the matched
+	// line, for labels that sit on a neighbouring line.
+	ContextLines int `yaml:"context_lines"`
 }
 
 type ValidationSpec struct {
@@ -69,12 +131,49 @@ type RuleTestCase struct {
 }
 
 func Load(defaultRulesDir string, customPath string) ([]Rule, error) {
-	all := map[string]Rule{}
-
 	defaultRules, err := loadFromPath(defaultRulesDir)
 	if err != nil {
 		return nil, err
 	}
+	return withCustom(defaultRules, customPath)
+}
+
+// CatalogVersion is the version of the rule catalog built into the binary.
+const CatalogVersion = catalog.Version
+
+// LoadBuiltin loads the rule catalog built into the binary and merges the
+// rules at customPath over it by ID.
+func LoadBuiltin(customPath string) ([]Rule, error) {
+	return LoadFS(catalog.FS, customPath)
+}
+
+// LoadFS is Load with the default rules read from the YAML files at the root
+// of fsys, such as an embedded catalog.
+func LoadFS(fsys fs.FS, customPath string) ([]Rule, error) {
+	names, err := fs.Glob(fsys, "*.y*ml")
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(names)
+	defaultRules := make([]Rule, 0)
+	for _, name := range names {
+		data, err := fs.ReadFile(fsys, name)
+		if err != nil {
+			return nil, fmt.Errorf("read rules file %s: %w", name, err)
+		}
+		loaded, err := parseRules(name, data)
+		if err != nil {
+			return nil, err
+		}
+		defaultRules = append(defaultRules, loaded...)
+	}
+	return withCustom(defaultRules, customPath)
+}
+
+// withCustom merges rules from customPath over the defaults by ID and drops
+// disabled rules.
+func withCustom(defaultRules []Rule, customPath string) ([]Rule, error) {
+	all := map[string]Rule{}
 	for _, r := range defaultRules {
 		all[r.ID] = r
 	}
@@ -91,6 +190,10 @@ func Load(defaultRulesDir string, customPath string) ([]Rule, error) {
 
 	out := make([]Rule, 0, len(all))
 	for _, r := range all {
+		// A custom entry with disabled: true turns off the rule with that ID.
+		if r.Disabled {
+			continue
+		}
 		out = append(out, r)
 	}
 
@@ -141,20 +244,33 @@ func loadFromPath(path string) ([]Rule, error) {
 		if err != nil {
 			return nil, fmt.Errorf("read rules file %s: %w", f, err)
 		}
-
-		var rf File
-		if err := yaml.Unmarshal(data, &rf); err != nil {
-			return nil, fmt.Errorf("parse rules file %s: %w", f, err)
+		rs, err := parseRules(f, data)
+		if err != nil {
+			return nil, err
 		}
+		loaded = append(loaded, rs...)
+	}
 
-		for _, r := range rf.Rules {
-			if err := compileRule(&r); err != nil {
-				return nil, fmt.Errorf("invalid rule %s: %w", r.ID, err)
-			}
+	return loaded, nil
+}
+
+func parseRules(name string, data []byte) ([]Rule, error) {
+	var rf File
+	if err := yaml.Unmarshal(data, &rf); err != nil {
+		return nil, fmt.Errorf("parse rules file %s: %w", name, err)
+	}
+
+	loaded := make([]Rule, 0, len(rf.Rules))
+	for _, r := range rf.Rules {
+		if r.Disabled {
 			loaded = append(loaded, r)
+			continue
+		}
+		if err := compileRule(&r); err != nil {
+			return nil, fmt.Errorf("invalid rule %s: %w", r.ID, err)
 		}
+		loaded = append(loaded, r)
 	}
-
 	return loaded, nil
 }
 
@@ -168,15 +284,46 @@ func compileRule(r *Rule) error {
 	if r.Severity == "" {
 		r.Severity = "medium"
 	}
-	if r.Detection.Regex == "" {
+	switch r.Type {
+	case "":
+		r.Type = TypeRegex
+	case TypeRegex:
+	case TypeEntropy, TypeTerraform, TypeKubernetes:
+		return nil
+	case TypeKeyValue:
+		if r.Detection.KeyPattern == "" {
+			return fmt.Errorf("missing detection.key_pattern")
+		}
+		re, err := regexp.Compile(r.Detection.KeyPattern)
+		if err != nil {
+			return fmt.Errorf("compile key pattern: %w", err)
+		}
+		r.KeyRegex = re
+	case TypeJWT:
+		if r.Detection.Regex == "" {
+			r.Detection.Regex = jwtPattern
+		}
+		if err := compileJWTSpec(r); err != nil {
+			return err
+		}
+	case TypeURI:
+		if r.Detection.Regex == "" {
+			r.Detection.Regex = uriPattern
+		}
+	default:
+		return fmt.Errorf("unknown rule type %q", r.Type)
+	}
+	if r.Detection.Regex == "" && r.Type == TypeRegex {
 		return fmt.Errorf("missing detection.regex")
 	}
 
-	re, err := regexp.Compile(r.Detection.Regex)
-	if err != nil {
-		return fmt.Errorf("compile detection regex: %w", err)
+	if r.Detection.Regex != "" {
+		re, err := regexp.Compile(r.Detection.Regex)
+		if err != nil {
+			return fmt.Errorf("compile detection regex: %w", err)
+		}
+		r.Regex = re
 	}
-	r.Regex = re
 
 	for _, w := range r.Detection.MustMatch {
 		pattern := choosePattern(w)
@@ -187,7 +334,10 @@ func compileRule(r *Rule) error {
 		if err != nil {
 			return fmt.Errorf("compile must_match regex: %w", err)
 		}
-		r.MustMatch = append(r.MustMatch, p)
+		if w.ContextLines < 0 {
+			return fmt.Errorf("must_match context_lines must not be negative")
+		}
+		r.MustMatch = append(r.MustMatch, ContextPattern{Regex: p, Lines: w.ContextLines})
 	}
 
 	for _, w := range r.Detection.MustNotMatch {
@@ -199,12 +349,44 @@ func compileRule(r *Rule) error {
 		if err != nil {
 			return fmt.Errorf("compile must_not_match regex: %w", err)
 		}
-		r.MustNotMatch = append(r.MustNotMatch, p)
+		if w.ContextLines < 0 {
+			return fmt.Errorf("must_not_match context_lines must not be negative")
+		}
+		r.MustNotMatch = append(r.MustNotMatch, ContextPattern{Regex: p, Lines: w.ContextLines})
 	}
 
 	return nil
 }
 
+func compileJWTSpec(r *Rule) error {
+	spec := r.Detection.JWT
+	for _, sev := range []string{spec.ExpiredSeverity, spec.AlgNoneSeverity, spec.NoExpirySeverity, spec.LongLivedSeverity} {
+		if sev == "" {
+			continue
+		}
+		if _, err := severity.Normalize(sev); err != nil {
+			return fmt.Errorf("detection.jwt: %w", err)
+		}
+	}
+	if spec.LongLivedAfter == "" {
+		return nil
+	}
+	if days, ok := strings.CutSuffix(spec.LongLivedAfter, "d"); ok {
+		n, err := strconv.Atoi(days)
+		if err != nil || n <= 0 {
+			return fmt.Errorf("detection.jwt.long_lived_after: invalid day count %q", spec.LongLivedAfter)
+		}
+		r.JWTLongLivedAfter = time.Duration(n) * 24 * time.Hour
+		return nil
+	}
+	d, err := time.ParseDuration(spec.LongLivedAfter)
+	if err != nil || d <= 0 {
+		return fmt.Errorf("detection.jwt.long_lived_after: invalid duration %q", spec.LongLivedAfter)
+	}
+	r.JWTLongLivedAfter = d
+	return nil
+}
+
 func choosePattern(w RegexWrapper) string {
 	if w.Regex != "" {
 		return w.Regex
@@ -213,13 +395,30 @@ func choosePattern(w RegexWrapper) string {
 }
 
 func MatchRule(r Rule, line string) bool {
-	if !r.Regex.MatchString(line) {
+	return MatchRuleInWindow(r, []string{line}, 0)
+}
+
+// MatchRuleInWindow matches lines[idx] against the rule, letting guards with
+// context_lines see the neighbouring lines.
+func MatchRuleInWindow(r Rule, lines []string, idx int) bool {
+	if r.Regex == nil || !r.Regex.MatchString(lines[idx]) {
 		return false
 	}
+	return MatchContextWindow(r, lines, idx, idx)
+}
+
+// MatchContext applies the rule's must_match/must_not_match guards to text.
+func MatchContext(r Rule, line string) bool {
+	return MatchContextWindow(r, []string{line}, 0, 0)
+}
+
+// MatchContextWindow applies the guards to lines[start..end], widened by each
+// pattern's context_lines.
+func MatchContextWindow(r Rule, lines []string, start int, end int) bool {
 	if len(r.MustMatch) > 0 {
 		matched := false
-		for _, re := range r.MustMatch {
-			if re.MatchString(line) {
+		for _, p := range r.MustMatch {
+			if p.Regex.MatchString(contextWindow(lines, start, end, p.Lines)) {
 				matched = true
 				break
 			}
@@ -228,16 +427,72 @@ func MatchRule(r Rule, line string) bool {
 			return false
 		}
 	}
-	for _, re := range r.MustNotMatch {
-		if re.MatchString(line) {
+	for _, p := range r.MustNotMatch {
+		if p.Regex.MatchString(contextWindow(lines, start, end, p.Lines)) {
 			return false
 		}
 	}
 	return true
 }
 
+// ContextLines returns the widest context window any guard of r uses.
+func ContextLines(r Rule) int {
+	n := 0
+	for _, p := range append(append([]ContextPattern{}, r.MustMatch...), r.MustNotMatch...) {
+		if p.Lines > n {
+			n = p.Lines
+		}
+	}
+	return n
+}
+
+func contextWindow(lines []string, start int, end int, n int) string {
+	lo := start - n
+	if lo < 0 {
+		lo = 0
+	}
+	hi := end + n + 1
+	if hi > len(lines) {
+		hi = len(lines)
+	}
+	return strings.Join(lines[lo:hi], "\n")
+}
+
 func TestRuleAgainstInput(r Rule, input string) bool {
-	return MatchRule(r, normalizeTestInput(input))
+	input = normalizeTestInput(input)
+	if r.Type == TypeKeyValue {
+		// Test inputs for keyvalue rules are single "key: value" or
+		// "key=value" assignments.
+		sep := strings.IndexAny(input, ":=")
+		if sep < 0 {
+			return false
+		}
+		key := strings.Trim(strings.TrimSpace(input[:sep]), `"'`)
+		value := strings.Trim(strings.TrimSpace(input[sep+1:]), `"',`)
+		return MatchKeyValue(r, key, value)
+	}
+	if r.Type == TypeURI {
+		if r.Regex == nil {
+			return false
+		}
+		for _, candidate := range r.Regex.FindAllString(input, -1) {
+			if _, ok := MatchConnectionString(r, candidate); ok {
+				return true
+			}
+		}
+		return false
+	}
+	if r.Detection.Multiline {
+		return MatchRule(r, input)
+	}
+	// Multi-line inputs pass when any line matches with its context window.
+	lines := strings.Split(strings.ReplaceAll(input, "\r\n", "\n"), "\n")
+	for i := range lines {
+		if MatchRuleInWindow(r, lines, i) {
+			return true
+		}
+	}
+	return false
 }
 
 func normalizeTestInput(input string) string {
@@ -0,0 +1,130 @@
+package rules
+
+import (
+	"net/url"
+	"strings"
+)
+
+// uriPattern finds URI-shaped candidates, including jdbc: URLs. uri rules
+// parse each candidate rather than relying on the regex for credentials.
+const uriPattern = "(?i)(?:jdbc:)?[a-z][a-z0-9+.-]*://[^\\s\"'<>`]+"
+
+// ConnectionString is a credential-bearing URI split into its parts.
+type ConnectionString struct {
+	Scheme   string
+	User     string
+	Password string
+	Host     string
+	// RawPassword is the password as written, before percent-decoding.
+	RawPassword string
+	// PasswordOffset is the byte offset of RawPassword in the parsed string.
+	PasswordOffset int
+}
+
+// ParseConnectionString extracts credentials from a URI such as
+// postgres://user:password@db:5432/x, redis://:password@cache or a JDBC URL
+// with password= in its query or ;-separated properties. ok is false when s
+// carries no password.
+func ParseConnectionString(s string) (ConnectionString, bool) {
+	var cs ConnectionString
+	rest, start := s, 0
+	if len(rest) > 5 && strings.EqualFold(rest[:5], "jdbc:") {
+		rest, start = rest[5:], 5
+	}
+	sep := strings.Index(rest, "://")
+	if sep <= 0 {
+		return cs, false
+	}
+	cs.Scheme = strings.ToLower(rest[:sep])
+	rest, start = rest[sep+3:], start+sep+3
+
+	// SQL Server style JDBC URLs put properties after ';' instead of a query.
+	var props string
+	propsStart := 0
+	if i := strings.IndexByte(rest, ';'); i >= 0 {
+		rest, props, propsStart = rest[:i], rest[i+1:], start+i+1
+	}
+	end := strings.IndexAny(rest, "/?#")
+	if end < 0 {
+		end = len(rest)
+	}
+	authority, tail := rest[:end], rest[end:]
+	if at := strings.LastIndexByte(authority, '@'); at >= 0 {
+		userinfo := authority[:at]
+		authority = authority[at+1:]
+		user, pass, hasPass := strings.Cut(userinfo, ":")
+		cs.User = unescape(user)
+		if hasPass {
+			cs.RawPassword, cs.Password = pass, unescape(pass)
+			cs.PasswordOffset = start + len(user) + 1
+		}
+	}
+	cs.Host = authority
+
+	type param struct {
+		text   string
+		offset int
+	}
+	params := make([]param, 0)
+	split := func(list string, offset int, sep string) {
+		for _, p := range strings.Split(list, sep) {
+			params = append(params, param{p, offset})
+			offset += len(p) + len(sep)
+		}
+	}
+	if i := strings.IndexByte(tail, '?'); i >= 0 {
+		query := tail[i+1:]
+		if j := strings.IndexByte(query, '#'); j >= 0 {
+			query = query[:j]
+		}
+		split(query, start+end+i+1, "&")
+	}
+	if props != "" {
+		split(props, propsStart, ";")
+	}
+	for _, p := range params {
+		k, v, _ := strings.Cut(p.text, "=")
+		switch strings.ToLower(k) {
+		case "password", "pwd":
+			if cs.Password == "" {
+				cs.RawPassword, cs.Password = v, unescape(v)
+				cs.PasswordOffset = p.offset + len(k) + 1
+			}
+		case "user", "username", "uid":
+			if cs.User == "" {
+				cs.User = unescape(v)
+			}
+		}
+	}
+	return cs, cs.Password != ""
+}
+
+// MatchConnectionString parses candidate for a uri rule. It fails when the
+// scheme is not in detection.schemes, the password is a placeholder, or the
+// rule's guards reject the candidate.
+func MatchConnectionString(r Rule, candidate string) (ConnectionString, bool) {
+	cs, ok := ParseConnectionString(candidate)
+	if !ok || IsPlaceholder(cs.Password) {
+		return cs, false
+	}
+	if len(r.Detection.Schemes) > 0 {
+		allowed := false
+		for _, s := range r.Detection.Schemes {
+			if strings.EqualFold(s, cs.Scheme) {
+				allowed = true
+				break
+			}
+		}
+		if !allowed {
+			return cs, false
+		}
+	}
+	return cs, MatchContext(r, candidate)
+}
+
+func unescape(s string) string {
+	if u, err := url.PathUnescape(s); err == nil {
+		return u
+	}
+	return s
+}
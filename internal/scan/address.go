@@ -0,0 +1,34 @@
+package scan
+
+import (
+	"strings"
+
+	"github.com/peter941221/secrethawk/internal/baseline"
+	"github.com/peter941221/secrethawk/internal/model"
+)
+
+// locationKey identifies where a finding sits for IDs: the file, followed by
+// the address inside it for values of parsed files, e.g.
+// infra/terraform.tfstate#aws_iam_access_key.ci.secret.
+func locationKey(loc model.Location) string {
+	if loc.Address == "" {
+		return loc.File
+	}
+	return loc.File + "#" + loc.Address
+}
+
+// relocate moves a finding matched in an extracted value to address within
+// file, on line lineNo of the original content. Columns point at the secret
+// when it appears verbatim on the line and at the whole line otherwise.
+func relocate(f *model.Finding, file string, address string, lineNo int, line string) {
+	f.Location.File = file
+	f.Location.Address = address
+	f.Location.LineStart = lineNo
+	f.Location.LineEnd = lineNo
+	f.Location.ColumnStart, f.Location.ColumnEnd = 1, max(len(line), 1)
+	if col := strings.Index(line, f.RawSecret); col >= 0 && f.RawSecret != "" {
+		f.Location.ColumnStart, f.Location.ColumnEnd = col+1, col+len(f.RawSecret)
+	}
+	f.LineHash = baseline.ComputeLineHash(line)
+	f.ID = findingID(f.RuleID, locationKey(f.Location), lineNo, f.LineHash, f.Location.Commit)
+}
@@ -0,0 +1,184 @@
+package scan
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"io"
+	"path"
+	"strings"
+
+	"github.com/peter941221/secrethawk/internal/model"
+)
+
+const (
+	// archiveSeparator joins an archive path and an entry path, e.g.
+	// dist/app.jar!/config/application.properties.
+	archiveSeparator = "!/"
+	maxArchiveDepth  = 3
+	// maxArchiveExpansion bounds the total bytes inflated from one top-level
+	// archive, as a multiple of the per-file size limit.
+	maxArchiveExpansion = 8
+)
+
+// IsArchiveEntry reports whether path points inside an archive rather than
+// at a file on disk.
+func IsArchiveEntry(p string) bool {
+	return strings.Contains(p, archiveSeparator)
+}
+
+type archiveBudget struct {
+	remaining int64
+}
+
+func (b *archiveBudget) take(n int64) bool {
+	if n > b.remaining {
+		return false
+	}
+	b.remaining -= n
+	return true
+}
+
+func archiveKind(name string, data []byte) string {
+	lower := strings.ToLower(name)
+	switch {
+	case bytes.HasPrefix(data, []byte("PK\x03\x04")), bytes.HasPrefix(data, []byte("PK\x05\x06")):
+		return "zip"
+	case len(data) > 2 && data[0] == 0x1f && data[1] == 0x8b:
+		return "gzip"
+	case len(data) > 262 && string(data[257:262]) == "ustar":
+		return "tar"
+	case strings.HasSuffix(lower, ".tar"):
+		return "tar"
+	}
+	return ""
+}
+
+func (e *engine) scanArchive(archivePath string, data []byte) ([]model.Finding, error) {
+	budget := &archiveBudget{remaining: e.maxSizeBytes * maxArchiveExpansion}
+	return e.scanArchiveData(archivePath, data, 1, budget)
+}
+
+func (e *engine) scanArchiveData(archivePath string, data []byte, depth int, budget *archiveBudget) ([]model.Finding, error) {
+	findings := make([]model.Finding, 0)
+	visit := func(name string, r io.Reader) error {
+		name = strings.TrimPrefix(path.Clean("/"+name), "/")
+		entryPath := archivePath + archiveSeparator + name
+		if shouldExcludePath(entryPath, e.policy) {
+			return nil
+		}
+		content, ok := readLimited(r, e.maxSizeBytes, budget)
+		if !ok {
+			return nil
+		}
+		if archiveKind(name, content) != "" {
+			if depth >= maxArchiveDepth {
+				return nil
+			}
+			nested, err := e.scanArchiveData(entryPath, content, depth+1, budget)
+			if err != nil {
+				return err
+			}
+			findings = append(findings, nested...)
+			return nil
+		}
+		if isBinary(content) {
+			return nil
+		}
+		entryFindings, err := e.scanContent(entryPath, string(content))
+		if err != nil {
+			return err
+		}
+		findings = append(findings, entryFindings...)
+		return nil
+	}
+
+	var err error
+	switch archiveKind(archivePath, data) {
+	case "zip":
+		err = walkZip(data, visit)
+	case "tar":
+		err = walkTar(bytes.NewReader(data), visit)
+	case "gzip":
+		err = walkGzip(archivePath, data, visit)
+	}
+	if err != nil && !errors.Is(err, errCorruptArchive) {
+		return nil, err
+	}
+	return findings, nil
+}
+
+var errCorruptArchive = errors.New("corrupt archive")
+
+func walkZip(data []byte, visit func(string, io.Reader) error) error {
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return errCorruptArchive
+	}
+	for _, f := range zr.File {
+		if f.FileInfo().IsDir() {
+			continue
+		}
+		rc, err := f.Open()
+		if err != nil {
+			continue
+		}
+		err = visit(f.Name, rc)
+		rc.Close()
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func walkTar(r io.Reader, visit func(string, io.Reader) error) error {
+	tr := tar.NewReader(r)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return errCorruptArchive
+		}
+		if hdr.Typeflag != tar.TypeReg {
+			continue
+		}
+		if err := visit(hdr.Name, tr); err != nil {
+			return err
+		}
+	}
+}
+
+// walkGzip handles both .tar.gz bundles and single gzip-compressed files.
+func walkGzip(archivePath string, data []byte, visit func(string, io.Reader) error) error {
+	gz, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return errCorruptArchive
+	}
+	defer gz.Close()
+	br := bufio.NewReader(gz)
+	if head, _ := br.Peek(262); len(head) == 262 && string(head[257:262]) == "ustar" {
+		return walkTar(br, visit)
+	}
+	name := gz.Name
+	if name == "" {
+		name = strings.TrimSuffix(path.Base(archivePath), path.Ext(archivePath))
+	}
+	return visit(name, br)
+}
+
+func readLimited(r io.Reader, maxSizeBytes int64, budget *archiveBudget) ([]byte, bool) {
+	content, err := io.ReadAll(io.LimitReader(r, maxSizeBytes+1))
+	if err != nil || int64(len(content)) > maxSizeBytes {
+		return nil, false
+	}
+	if !budget.take(int64(len(content))) {
+		return nil, false
+	}
+	return content, true
+}
@@ -0,0 +1,218 @@
+package scan
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/peter941221/secrethawk/internal/config"
+	"github.com/peter941221/secrethawk/internal/model"
+	"github.com/peter941221/secrethawk/internal/rules"
+)
+
+const cacheFormatVersion = "2"
+
+// DefaultCacheDir is where the CLI keeps per-file scan results between runs,
+// relative to the scanned directory.
+const DefaultCacheDir = ".secrethawk/cache"
+
+// CacheDirFor returns the cache directory used when scanning target: inside
+// target when it is a directory, otherwise relative to the working directory.
+func CacheDirFor(target string) string {
+	if info, err := os.Stat(target); err == nil && info.IsDir() {
+		return filepath.Join(target, DefaultCacheDir)
+	}
+	return DefaultCacheDir
+}
+
+type cacheFile struct {
+	Version string                `json:"version"`
+	Digest  string                `json:"digest"`
+	Entries map[string]cacheEntry `json:"entries"`
+}
+
+type cacheEntry struct {
+	ContentHash string          `json:"content_hash"`
+	Findings    []cachedFinding `json:"findings"`
+}
+
+// cachedFinding keeps the line hash model.Finding hides from JSON. The raw
+// secret is never written: on a hit it is read back from the unchanged file
+// at the finding's line and columns.
+type cachedFinding struct {
+	Finding  model.Finding `json:"finding"`
+	LineHash string        `json:"line_hash"`
+}
+
+// scanCache maps file paths to the findings produced for a given content
+// hash. One cache file exists per rules/policy digest, so editing rules or
+// the allowlist starts from an empty cache.
+type scanCache struct {
+	dir     string
+	digest  string
+	prev    map[string]cacheEntry
+	mu      sync.Mutex
+	next    map[string]cacheEntry
+	hits    int
+	changed bool
+	// prune drops entries for paths not seen in this run; only full
+	// directory scans know the complete file set.
+	prune bool
+}
+
+func openScanCache(dir string, digest string) *scanCache {
+	c := &scanCache{dir: dir, digest: digest, prev: map[string]cacheEntry{}, next: map[string]cacheEntry{}}
+	data, err := os.ReadFile(c.path())
+	if err != nil {
+		return c
+	}
+	var f cacheFile
+	if err := json.Unmarshal(data, &f); err != nil || f.Version != cacheFormatVersion || f.Digest != digest {
+		return c
+	}
+	if f.Entries != nil {
+		c.prev = f.Entries
+	}
+	return c
+}
+
+func (c *scanCache) path() string {
+	return filepath.Join(c.dir, "scan-"+c.digest[:16]+".json")
+}
+
+func (c *scanCache) lookup(path string, data []byte, contentHash string) ([]model.Finding, bool) {
+	e, ok := c.prev[path]
+	if !ok || e.ContentHash != contentHash {
+		return nil, false
+	}
+	lines := strings.Split(strings.ReplaceAll(string(data), "\r\n", "\n"), "\n")
+	findings := make([]model.Finding, 0, len(e.Findings))
+	for _, cf := range e.Findings {
+		f := cf.Finding
+		f.LineHash = cf.LineHash
+		secret, ok := secretAt(lines, f.Location)
+		if !ok {
+			return nil, false
+		}
+		f.RawSecret = secret
+		findings = append(findings, f)
+	}
+	c.mu.Lock()
+	c.next[path] = e
+	c.hits++
+	c.mu.Unlock()
+	return findings, true
+}
+
+// store records the findings for path. Files with a finding whose secret
+// cannot be read back from its location, such as decoded values, multi-line
+// keys or findings inside archives and parsed documents, are not cached and
+// are rescanned next time.
+func (c *scanCache) store(path string, data []byte, contentHash string, findings []model.Finding) {
+	lines := strings.Split(strings.ReplaceAll(string(data), "\r\n", "\n"), "\n")
+	e := cacheEntry{ContentHash: contentHash, Findings: make([]cachedFinding, 0, len(findings))}
+	for _, f := range findings {
+		if secret, ok := secretAt(lines, f.Location); !ok || secret != f.RawSecret || f.Location.File != path {
+			return
+		}
+		e.Findings = append(e.Findings, cachedFinding{Finding: f, LineHash: f.LineHash})
+	}
+	c.mu.Lock()
+	c.next[path] = e
+	c.changed = true
+	c.mu.Unlock()
+}
+
+// save writes entries seen during this run and drops cache files created for
+// other rule/policy digests. The directory ignores itself in git.
+func (c *scanCache) save() error {
+	if !c.prune {
+		for p, e := range c.prev {
+			if _, ok := c.next[p]; !ok {
+				c.next[p] = e
+			}
+		}
+	}
+	if !c.changed && len(c.next) == len(c.prev) {
+		return nil
+	}
+	if err := os.MkdirAll(c.dir, 0o700); err != nil {
+		return err
+	}
+	if err := os.WriteFile(filepath.Join(c.dir, ".gitignore"), []byte("*\n"), 0o644); err != nil {
+		return err
+	}
+	data, err := json.Marshal(cacheFile{Version: cacheFormatVersion, Digest: c.digest, Entries: c.next})
+	if err != nil {
+		return err
+	}
+	tmp := c.path() + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o600); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, c.path()); err != nil {
+		return err
+	}
+	stale, _ := filepath.Glob(filepath.Join(c.dir, "scan-*.json"))
+	for _, p := range stale {
+		if p != c.path() {
+			_ = os.Remove(p)
+		}
+	}
+	return nil
+}
+
+// ClearCache removes every cached scan result under dir.
+func ClearCache(dir string) error {
+	if dir == "" {
+		dir = DefaultCacheDir
+	}
+	if err := os.RemoveAll(dir); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+// secretAt returns the text a single-line finding points at.
+func secretAt(lines []string, loc model.Location) (string, bool) {
+	if loc.LineStart != loc.LineEnd || loc.LineStart < 1 || loc.LineStart > len(lines) {
+		return "", false
+	}
+	line := lines[loc.LineStart-1]
+	if loc.ColumnStart < 1 || loc.ColumnEnd < loc.ColumnStart || loc.ColumnEnd > len(line) {
+		return "", false
+	}
+	return line[loc.ColumnStart-1 : loc.ColumnEnd], true
+}
+
+func cacheDigest(allRules []rules.Rule, policy config.Policy, threshold string, maxSizeBytes int64, version string) string {
+	payload, _ := json.Marshal(struct {
+		Format    string
+		Version   string
+		Rules     []rules.Rule
+		Policy    config.Policy
+		Threshold string
+		MaxSize   int64
+	}{cacheFormatVersion, version, allRules, policy, threshold, maxSizeBytes})
+	sum := sha256.Sum256(payload)
+	return hex.EncodeToString(sum[:])
+}
+
+func contentHash(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+func isCacheDir(path string, cacheDir string) bool {
+	if cacheDir == "" {
+		return false
+	}
+	a, err1 := filepath.Abs(path)
+	b, err2 := filepath.Abs(cacheDir)
+	return err1 == nil && err2 == nil && filepath.Clean(a) == filepath.Clean(b)
+}
@@ -0,0 +1,101 @@
+package scan
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRunReusesCachedFindingsUntilPolicyChanges(t *testing.T) {
+	tmp := t.TempDir()
+	if err := os.WriteFile(filepath.Join(tmp, "config.py"), []byte(fmt.Sprintf("aws_key = %q\n", testAWSKey())), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(tmp, "clean.py"), []byte("print('hello')\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	policyPath := filepath.Join(tmp, "policy.yaml")
+	cacheDir := filepath.Join(tmp, ".secrethawk", "cache")
+
+	run := func() Result {
+		res, err := Run(context.Background(), Options{
+			Target:             tmp,
+			PolicyPath:         policyPath,
+			BaselinePath:       filepath.Join(tmp, "baseline.json"),
+			Severity:           "critical",
+			MaxTargetMegabytes: 5,
+			Version:            "test",
+			CacheDir:           cacheDir,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return res
+	}
+
+	first := run()
+	if first.Report.Metadata.CacheHits != 0 || len(first.Report.Findings) != 1 {
+		t.Fatalf("unexpected first run: hits=%d findings=%d", first.Report.Metadata.CacheHits, len(first.Report.Findings))
+	}
+
+	second := run()
+	if second.Report.Metadata.CacheHits != 2 {
+		t.Fatalf("expected 2 cache hits, got %d", second.Report.Metadata.CacheHits)
+	}
+	if second.Report.Metadata.FilesScanned != 2 {
+		t.Fatalf("cache dir should not be scanned, files=%d", second.Report.Metadata.FilesScanned)
+	}
+	if len(second.Report.Findings) != 1 || second.Report.Findings[0].RawSecret != testAWSKey() || second.Report.Findings[0].LineHash == "" {
+		t.Fatalf("cached finding lost hidden fields: %+v", second.Report.Findings)
+	}
+	cached, _ := filepath.Glob(filepath.Join(cacheDir, "scan-*.json"))
+	if len(cached) != 1 {
+		t.Fatalf("expected one cache file, got %v", cached)
+	}
+	data, err := os.ReadFile(cached[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(data), testAWSKey()) {
+		t.Fatalf("cache file stores the raw secret: %s", data)
+	}
+
+	policy := "version: \"1\"\nallowlist:\n  patterns:\n    - regex: 'not-a-secret'\n"
+	if err := os.WriteFile(policyPath, []byte(policy), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	third := run()
+	if third.Report.Metadata.CacheHits != 0 {
+		t.Fatalf("expected policy change to invalidate cache, got %d hits", third.Report.Metadata.CacheHits)
+	}
+}
+
+func TestRunWarnsWhenCacheCannotBeWritten(t *testing.T) {
+	tmp := t.TempDir()
+	if err := os.WriteFile(filepath.Join(tmp, "config.py"), []byte(fmt.Sprintf("aws_key = %q\n", testAWSKey())), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	blocker := filepath.Join(tmp, "not-a-dir")
+	if err := os.WriteFile(blocker, nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	res, err := Run(context.Background(), Options{
+		Target:             filepath.Join(tmp, "config.py"),
+		PolicyPath:         filepath.Join(tmp, "policy.yaml"),
+		BaselinePath:       filepath.Join(tmp, "baseline.json"),
+		Severity:           "critical",
+		MaxTargetMegabytes: 5,
+		Version:            "test",
+		CacheDir:           filepath.Join(blocker, "cache"),
+	})
+	if err != nil {
+		t.Fatalf("cache write failure should not abort the scan: %v", err)
+	}
+	if len(res.Report.Findings) != 1 || len(res.Warnings) != 1 {
+		t.Fatalf("expected 1 finding and 1 warning, got %+v %v", res.Report.Findings, res.Warnings)
+	}
+}
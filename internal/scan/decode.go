@@ -0,0 +1,204 @@
+package scan
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/peter941221/secrethawk/internal/baseline"
+	"github.com/peter941221/secrethawk/internal/model"
+)
+
+// maxDecodeDepth bounds how many nested encodings are peeled off a token.
+const maxDecodeDepth = 3
+
+// minDecodedLength skips decoded values too short to contain a credential.
+const minDecodedLength = 8
+
+var (
+	base64TokenRE = regexp.MustCompile(`[A-Za-z0-9+/_-]{16,}={0,2}`)
+	hexTokenRE    = regexp.MustCompile(`^(?:[0-9a-fA-F]{2}){8,}$`)
+	urlTokenRE    = regexp.MustCompile(`[^\s"'<>` + "`" + `]*%[0-9A-Fa-f]{2}[^\s"'<>` + "`" + `]*`)
+)
+
+// encodedToken is a decodable token found on a line.
+type encodedToken struct {
+	Token    string
+	Column   int
+	Decoded  string
+	Encoding string
+}
+
+// scanDecoded looks for base64, hex and URL-encoded tokens, decodes them and
+// runs the rule set on the decoded text. Findings are reported at the encoded
+// token with Match.Encoding naming the encodings that were peeled off. The
+// returned map lists, per line, the tokens that produced at least one finding.
+func (e *engine) scanDecoded(path string, text string) ([]model.Finding, map[int][]string) {
+	findings := make([]model.Finding, 0)
+	fired := map[int][]string{}
+	seen := map[string]struct{}{}
+	lines := strings.Split(strings.ReplaceAll(text, "\r\n", "\n"), "\n")
+	for i, line := range lines {
+		lineNo := i + 1
+		for _, tok := range decodeTokens(line) {
+			fnds := e.scanDecodedText(path, tok.Decoded, tok.Encoding, 1)
+			if len(fnds) == 0 {
+				continue
+			}
+			fired[lineNo] = append(fired[lineNo], tok.Token)
+			lineHash := baseline.ComputeLineHash(tok.Token)
+			for _, f := range fnds {
+				// Overlapping tokens (e.g. URL-escaped base64) can decode to
+				// the same secret; report it once per line.
+				key := fmt.Sprintf("%d|%s|%s", lineNo, f.RuleID, f.RawSecret)
+				if _, dup := seen[key]; dup {
+					continue
+				}
+				seen[key] = struct{}{}
+				f.Location.LineStart = lineNo
+				f.Location.LineEnd = lineNo
+				f.Location.ColumnStart = tok.Column + 1
+				f.Location.ColumnEnd = tok.Column + len(tok.Token)
+				f.LineHash = lineHash
+				f.ID = findingID(f.RuleID, path, lineNo, lineHash, nil)
+				findings = append(findings, f)
+			}
+		}
+	}
+	return findings, fired
+}
+
+// scanDecodedText matches rules against decoded text and recurses into any
+// further encoded tokens it contains.
+func (e *engine) scanDecodedText(path string, decoded string, encoding string, depth int) []model.Finding {
+	findings := e.scanMultiline(path, decoded)
+	lines := strings.Split(strings.ReplaceAll(decoded, "\r\n", "\n"), "\n")
+	for i, line := range lines {
+		findings = append(findings, e.scanLine(path, lines, i)...)
+		if depth >= maxDecodeDepth {
+			continue
+		}
+		for _, tok := range decodeTokens(line) {
+			findings = append(findings, e.scanDecodedText(path, tok.Decoded, encoding+"+"+tok.Encoding, depth+1)...)
+		}
+	}
+	for i := range findings {
+		if findings[i].Match.Encoding == "" {
+			findings[i].Match.Encoding = encoding
+		}
+	}
+	return findings
+}
+
+// decodeTokens returns every token on the line that decodes to printable text.
+func decodeTokens(line string) []encodedToken {
+	tokens := make([]encodedToken, 0)
+	for _, loc := range urlTokenRE.FindAllStringIndex(line, -1) {
+		tok := line[loc[0]:loc[1]]
+		decoded, err := url.QueryUnescape(tok)
+		if err != nil {
+			decoded, err = url.PathUnescape(tok)
+		}
+		if err != nil || decoded == tok || !printableText(decoded) {
+			continue
+		}
+		tokens = append(tokens, encodedToken{Token: tok, Column: loc[0], Decoded: decoded, Encoding: "url"})
+	}
+	for _, loc := range base64TokenRE.FindAllStringIndex(line, -1) {
+		tok := line[loc[0]:loc[1]]
+		if hexTokenRE.MatchString(tok) {
+			if b, err := hex.DecodeString(tok); err == nil && printableText(string(b)) {
+				tokens = append(tokens, encodedToken{Token: tok, Column: loc[0], Decoded: string(b), Encoding: "hex"})
+			}
+			continue
+		}
+		if b, ok := decodeBase64(tok); ok && printableText(string(b)) {
+			tokens = append(tokens, encodedToken{Token: tok, Column: loc[0], Decoded: string(b), Encoding: "base64"})
+		}
+	}
+	return tokens
+}
+
+func decodeBase64(tok string) ([]byte, bool) {
+	trimmed := strings.TrimRight(tok, "=")
+	enc := base64.RawStdEncoding
+	if strings.ContainsAny(trimmed, "-_") {
+		if strings.ContainsAny(trimmed, "+/") {
+			return nil, false
+		}
+		enc = base64.RawURLEncoding
+	}
+	b, err := enc.DecodeString(trimmed)
+	if err != nil {
+		return nil, false
+	}
+	return b, true
+}
+
+// printableText reports whether decoded bytes look like text rather than
+// binary noise, which is what most random tokens decode to.
+func printableText(s string) bool {
+	return len(s) >= minDecodedLength && isText(s)
+}
+
+// isText reports whether s is valid UTF-8 without control characters other
+// than line breaks and tabs.
+func isText(s string) bool {
+	if !utf8.ValidString(s) {
+		return false
+	}
+	for _, r := range s {
+		if r == '\n' || r == '\r' || r == '\t' {
+			continue
+		}
+		if !unicode.IsPrint(r) {
+			return false
+		}
+	}
+	return true
+}
+
+// dropRepeated removes decoded findings whose rule already matched the same
+// secret in plain text on the same line, such as a key inside a URL that
+// also contains escapes.
+func dropRepeated(decoded []model.Finding, plain []model.Finding) []model.Finding {
+	seen := map[string]struct{}{}
+	for _, f := range plain {
+		seen[fmt.Sprintf("%d|%s|%s", f.Location.LineStart, f.RuleID, f.RawSecret)] = struct{}{}
+	}
+	kept := decoded[:0]
+	for _, f := range decoded {
+		if _, dup := seen[fmt.Sprintf("%d|%s|%s", f.Location.LineStart, f.RuleID, f.RawSecret)]; !dup {
+			kept = append(kept, f)
+		}
+	}
+	return kept
+}
+
+// dropOverlapping removes findings whose secret overlaps a token already
+// reported on the same line, e.g. entropy matches on an encoded token whose
+// decoded text produced a specific finding.
+func dropOverlapping(findings []model.Finding, covered map[int][]string) []model.Finding {
+	if len(covered) == 0 {
+		return findings
+	}
+	kept := findings[:0]
+	for _, f := range findings {
+		overlap := false
+		for _, tok := range covered[f.Location.LineStart] {
+			if strings.Contains(tok, f.RawSecret) || strings.Contains(f.RawSecret, tok) {
+				overlap = true
+				break
+			}
+		}
+		if !overlap {
+			kept = append(kept, f)
+		}
+	}
+	return kept
+}
@@ -0,0 +1,77 @@
+package scan
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestRunDetectsEncodedSecrets(t *testing.T) {
+	tmp := t.TempDir()
+	b64 := base64.StdEncoding.EncodeToString([]byte(testAWSKey()))
+	nested := url.QueryEscape(base64.StdEncoding.EncodeToString([]byte("aws: " + testAWSKey())))
+	source := fmt.Sprintf("data:\n  AWS_KEY: %s\n  HEX: %s\n  URL: %s\n",
+		b64, hex.EncodeToString([]byte("key: "+testAWSKey())), nested)
+	if err := os.WriteFile(filepath.Join(tmp, "secret.yaml"), []byte(source), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	res, err := Run(context.Background(), Options{
+		Target:             tmp,
+		PolicyPath:         filepath.Join(tmp, "policy.yaml"),
+		BaselinePath:       filepath.Join(tmp, "baseline.json"),
+		Severity:           "low",
+		MaxTargetMegabytes: 5,
+		Version:            "test",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := map[int]string{2: "base64", 3: "hex", 4: "url+base64"}
+	for _, f := range res.Report.Findings {
+		if f.RuleID == "generic-high-entropy" {
+			t.Fatalf("entropy match on decoded token should be dropped: %+v", f.Location)
+		}
+		enc, ok := want[f.Location.LineStart]
+		if !ok || f.RuleID != "aws-access-key-id" || f.Match.Encoding != enc {
+			t.Fatalf("unexpected finding line=%d rule=%s encoding=%q", f.Location.LineStart, f.RuleID, f.Match.Encoding)
+		}
+		if f.RawSecret != testAWSKey() || f.Location.ColumnStart <= 1 {
+			t.Fatalf("unexpected secret or column: %+v", f.Location)
+		}
+		delete(want, f.Location.LineStart)
+	}
+	if len(want) != 0 {
+		t.Fatalf("missing encoded findings: %v", want)
+	}
+}
+
+func TestRunDoesNotRepeatPlaintextSecretsAsDecoded(t *testing.T) {
+	tmp := t.TempDir()
+	source := "CALLBACK: https://example.com/cb?note=hello%20world&aws:" + testAWSKey() + "\n"
+	if err := os.WriteFile(filepath.Join(tmp, "app.env"), []byte(source), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	res, err := Run(context.Background(), Options{
+		Target:             tmp,
+		PolicyPath:         filepath.Join(tmp, "policy.yaml"),
+		BaselinePath:       filepath.Join(tmp, "baseline.json"),
+		Severity:           "critical",
+		MaxTargetMegabytes: 5,
+		Version:            "test",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(res.Report.Findings) != 1 || res.Report.Findings[0].Match.Encoding != "" {
+		t.Fatalf("expected one plaintext finding, got %+v", res.Report.Findings)
+	}
+}
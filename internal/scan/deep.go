@@ -0,0 +1,177 @@
+package scan
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/peter941221/secrethawk/internal/model"
+)
+
+// Deep-history sources, in the order they are collected. A commit found by an
+// earlier source is not scanned again for a later one.
+const (
+	sourceRef          = "ref"
+	sourceStash        = "stash"
+	sourceReflog       = "reflog"
+	sourceUnreachable  = "unreachable"
+	sourceDanglingBlob = "dangling-blob"
+)
+
+// scanDeepHistory extends the all-history scan to content that survives outside
+// branch history: every stash entry, commits only referenced by reflogs,
+// unreachable commits and dangling blobs. Findings carry their source in
+// Location.Source.
+func (e *engine) scanDeepHistory(ctx context.Context, repo string, threads int) ([]model.Finding, int, error) {
+	seen := map[string]struct{}{}
+	commits := make([]historyCommit, 0)
+	add := func(cs []historyCommit, source string) {
+		for _, c := range cs {
+			key := c.Base + ".." + c.SHA
+			if _, ok := seen[c.SHA]; ok {
+				continue
+			}
+			if _, ok := seen[key]; ok {
+				continue
+			}
+			seen[key] = struct{}{}
+			if c.Base == "" {
+				seen[c.SHA] = struct{}{}
+			}
+			c.Source = source
+			commits = append(commits, c)
+		}
+	}
+
+	refs, err := listHistoryCommits(ctx, repo, "--exclude=refs/stash", "--all")
+	if err != nil {
+		return nil, 0, err
+	}
+	add(refs, sourceRef)
+
+	stash, err := listStashCommits(ctx, repo)
+	if err != nil {
+		return nil, 0, err
+	}
+	add(stash, sourceStash)
+
+	reflog, err := listHistoryCommits(ctx, repo, "--reflog", "--not", "--all")
+	if err != nil {
+		return nil, 0, err
+	}
+	add(reflog, sourceReflog)
+
+	objects, err := fsckObjects(ctx, repo, "--unreachable")
+	if err != nil {
+		return nil, 0, err
+	}
+	if len(objects["commit"]) > 0 {
+		unreachable, err := listHistoryCommits(ctx, repo, append([]string{"--no-walk"}, objects["commit"]...)...)
+		if err != nil {
+			return nil, 0, err
+		}
+		add(unreachable, sourceUnreachable)
+	}
+
+	findings, filesScanned, err := e.scanCommits(ctx, repo, threads, commits)
+	if err != nil {
+		return nil, 0, err
+	}
+	if err := annotateBranches(ctx, repo, findings); err != nil {
+		return nil, 0, err
+	}
+
+	dangling, err := fsckObjects(ctx, repo)
+	if err != nil {
+		return nil, 0, err
+	}
+	for _, sha := range dangling["blob"] {
+		fnds, err := e.scanBlob(ctx, repo, sha)
+		if err != nil {
+			return nil, 0, err
+		}
+		if err := e.stream(fnds); err != nil {
+			return nil, 0, err
+		}
+		findings = append(findings, fnds...)
+		filesScanned++
+	}
+	return findings, filesScanned, nil
+}
+
+// listStashCommits returns scan entries for every stash in the refs/stash
+// reflog: the working tree and index states diffed against the stashed-on
+// commit, and the untracked-files commit when present.
+func listStashCommits(ctx context.Context, repo string) ([]historyCommit, error) {
+	if _, err := gitOutput(ctx, "-C", repo, "rev-parse", "--verify", "--quiet", "refs/stash"); err != nil {
+		return nil, nil
+	}
+	out, err := gitOutput(ctx, "-C", repo, "log", "--walk-reflogs", "--format="+commitLogFormat, "refs/stash")
+	if err != nil {
+		return nil, err
+	}
+	commits := make([]historyCommit, 0)
+	for _, w := range parseCommitLog(out) {
+		if len(w.Parents) < 2 {
+			continue
+		}
+		base := w.Parents[0]
+		worktree := w.historyCommit
+		worktree.Base = base
+		index := w.historyCommit
+		index.SHA, index.Base = w.Parents[1], base
+		commits = append(commits, worktree, index)
+		if len(w.Parents) > 2 {
+			untracked := w.historyCommit
+			untracked.SHA = w.Parents[2]
+			commits = append(commits, untracked)
+		}
+	}
+	return commits, nil
+}
+
+// fsckObjects groups the object ids git fsck reports by type. With no flags it
+// lists dangling objects; with --unreachable it lists every unreachable one.
+// Reflogs are not treated as roots so reflog-only objects are included.
+func fsckObjects(ctx context.Context, repo string, flags ...string) (map[string][]string, error) {
+	args := append([]string{"-C", repo, "fsck", "--no-reflogs", "--no-progress"}, flags...)
+	out, err := gitOutput(ctx, args...)
+	if err != nil {
+		return nil, err
+	}
+	objects := map[string][]string{}
+	for _, l := range strings.Split(out, "\n") {
+		fields := strings.Fields(l)
+		if len(fields) != 3 || (fields[0] != "dangling" && fields[0] != "unreachable") {
+			continue
+		}
+		objects[fields[1]] = append(objects[fields[1]], fields[2])
+	}
+	return objects, nil
+}
+
+// scanBlob scans a blob no tree refers to. There is no file name, so the
+// finding path is the blob id.
+func (e *engine) scanBlob(ctx context.Context, repo string, sha string) ([]model.Finding, error) {
+	size, err := gitOutput(ctx, "-C", repo, "cat-file", "-s", sha)
+	if err != nil {
+		return nil, err
+	}
+	var n int64
+	if _, err := fmt.Sscanf(strings.TrimSpace(size), "%d", &n); err != nil || n > e.maxSizeBytes {
+		return nil, nil
+	}
+	data, err := gitOutput(ctx, "-C", repo, "cat-file", "blob", sha)
+	if err != nil {
+		return nil, err
+	}
+	path := "blob:" + sha
+	fnds, err := e.scanData(path, []byte(data))
+	if err != nil {
+		return nil, err
+	}
+	for i := range fnds {
+		fnds[i].Location.Source = sourceDanglingBlob
+	}
+	return fnds, nil
+}
@@ -1,12 +1,12 @@
 package scan
 
 import (
-	"bufio"
 	"context"
 	"crypto/sha1"
 	"encoding/hex"
 	"errors"
 	"fmt"
+	"io"
 	"io/fs"
 	"math"
 	"os"
@@ -24,35 +24,81 @@ import (
 	"github.com/peter941221/secrethawk/internal/config"
 	"github.com/peter941221/secrethawk/internal/connector"
 	"github.com/peter941221/secrethawk/internal/model"
+	"github.com/peter941221/secrethawk/internal/offline"
 	"github.com/peter941221/secrethawk/internal/rules"
 	"github.com/peter941221/secrethawk/internal/severity"
 )
 
 type Options struct {
-	Target             string
-	Staged             bool
-	SinceRef           string
-	AllHistory         bool
-	RulesPath          string
-	PolicyPath         string
-	BaselinePath       string
-	Severity           string
-	Validate           bool
+	Target     string
+	Staged     bool
+	SinceRef   string
+	AllHistory bool
+	// DeepHistory extends AllHistory to stashes, reflog entries, unreachable
+	// commits and dangling blobs.
+	DeepHistory  bool
+	RulesPath    string
+	PolicyPath   string
+	BaselinePath string
+	Severity     string
+	Validate     bool
+	// OfflineValidation limits Validate to offline structure and checksum
+	// checks, for runners without network egress.
+	OfflineValidation  bool
 	FailOn             string
 	FailOnActive       bool
 	MaxTargetMegabytes int
 	Threads            int
 	Version            string
 	Now                time.Time
+	// Stdin is read instead of walking Target when Target is "-".
+	Stdin         io.Reader
+	StdinFilename string
+	// ImageTar is a `docker save` tarball or OCI layout directory to scan
+	// layer by layer instead of Target.
+	ImageTar string
+	// CacheDir enables the per-file findings cache for working tree scans.
+	CacheDir string
+	// NoGitignore disables .gitignore handling in directory scans;
+	// .secrethawkignore files still apply.
+	NoGitignore bool
+	// RefUpdates switches to pre-receive mode: only commits introduced by
+	// these ref updates are scanned in the repository at Target.
+	RefUpdates []RefUpdate
+	// HistoryRange scans only the commits in this revision range, e.g.
+	// "main..feature", of the repository at Target.
+	HistoryRange string
+	// Rules and Policy, when set, are used as loaded instead of merging
+	// RulesPath over the built-in catalog and reading PolicyPath.
+	Rules  []rules.Rule
+	Policy *config.Policy
 }
 
 type Result struct {
 	Report      model.FindingReport
 	ShouldFail  bool
 	ScannedMode string
+	// Warnings lists problems that did not affect the findings, such as a
+	// cache directory that could not be written.
+	Warnings []string
 }
 
+// Run scans opts.Target and returns the complete, sorted report.
 func Run(ctx context.Context, opts Options) (Result, error) {
+	return run(ctx, opts, nil)
+}
+
+// RunStream scans like Run but also passes each finding to fn as soon as a
+// worker produces it, after baseline filtering and, with opts.Validate,
+// validation. fn is never called concurrently; an error from fn aborts the
+// scan. Streamed findings are not grouped or annotated with branches, so in
+// history modes they are the individual occurrences of the grouped findings
+// in the returned report.
+func RunStream(ctx context.Context, opts Options, fn func(model.Finding) error) (Result, error) {
+	return run(ctx, opts, fn)
+}
+
+func run(ctx context.Context, opts Options, fn func(model.Finding) error) (Result, error) {
 	if opts.Target == "" {
 		opts.Target = "."
 	}
@@ -78,19 +124,23 @@ func Run(ctx context.Context, opts Options) (Result, error) {
 		threshold = v
 	}
 
-	policy, err := config.LoadPolicy(opts.PolicyPath)
-	if err != nil {
-		return Result{}, err
-	}
-
-	defaultRulesDir, err := resolveRulesDir("rules")
-	if err != nil {
-		return Result{}, err
+	var policy config.Policy
+	var err error
+	if opts.Policy != nil {
+		policy = *opts.Policy
+	} else {
+		policy, err = config.LoadPolicy(opts.PolicyPath)
+		if err != nil {
+			return Result{}, err
+		}
 	}
 
-	allRules, err := rules.Load(defaultRulesDir, opts.RulesPath)
-	if err != nil {
-		return Result{}, err
+	allRules := opts.Rules
+	if allRules == nil {
+		allRules, err = rules.LoadBuiltin(opts.RulesPath)
+		if err != nil {
+			return Result{}, err
+		}
 	}
 
 	base, err := baseline.Load(opts.BaselinePath)
@@ -99,24 +149,81 @@ func Run(ctx context.Context, opts Options) (Result, error) {
 	}
 
 	start := time.Now()
+	eng := newEngine(allRules, policy, threshold, int64(opts.MaxTargetMegabytes)*1024*1024)
+	eng.now = opts.Now
+	if fn != nil {
+		// History modes stream every occurrence of a secret; each rule and
+		// secret pair is checked with its provider once.
+		validated := map[string]connectorResult{}
+		eng.emit = func(batch []model.Finding) error {
+			for i := range batch {
+				if baseline.IsSuppressed(base, batch[i]) {
+					continue
+				}
+				if opts.Validate && batch[i].Suppression == nil {
+					validateFinding(ctx, &batch[i], eng.scanTime(), opts.OfflineValidation, validated)
+				}
+				if err := fn(batch[i]); err != nil {
+					return err
+				}
+			}
+			return nil
+		}
+	}
 
 	mode := "directory"
 	var findings []model.Finding
 	var filesScanned int
-	if opts.AllHistory {
+	var cache *scanCache
+	skipped := map[string]int{}
+	if opts.DeepHistory {
+		mode = "deep-history"
+		findings, filesScanned, err = eng.scanDeepHistory(ctx, opts.Target, opts.Threads)
+	} else if opts.AllHistory {
 		mode = "all-history"
-		findings, filesScanned, err = scanAllHistory(ctx, allRules, policy, threshold)
+		findings, filesScanned, err = eng.scanHistory(ctx, opts.Target, opts.Threads)
+	} else if opts.HistoryRange != "" {
+		mode = "history-range"
+		findings, filesScanned, err = eng.scanHistoryRange(ctx, opts.Target, opts.Threads, opts.HistoryRange)
+	} else if opts.RefUpdates != nil {
+		mode = "pre-receive"
+		findings, filesScanned, err = eng.scanRefUpdates(ctx, opts.Target, opts.Threads, opts.RefUpdates)
+	} else if opts.ImageTar != "" {
+		mode = "image"
+		opts.Target = opts.ImageTar
+		findings, filesScanned, err = eng.scanImage(opts.ImageTar)
+	} else if opts.Target == "-" {
+		mode = "stdin"
+		findings, filesScanned, err = eng.scanStdin(opts)
 	} else {
 		if opts.Staged {
 			mode = "staged"
 		} else if opts.SinceRef != "" {
 			mode = "since"
 		}
-		findings, filesScanned, err = scanWorkingTree(ctx, opts, allRules, policy, threshold)
+		if opts.CacheDir != "" {
+			digest := cacheDigest(allRules, policy, threshold, eng.maxSizeBytes, opts.Version)
+			cache = openScanCache(opts.CacheDir, digest)
+			cache.prune = mode == "directory"
+		}
+		findings, filesScanned, err = scanWorkingTree(ctx, opts, eng, cache, skipped)
+	}
+	if err == nil && (mode == "image" || mode == "stdin") {
+		// These modes scan sequentially and label findings after the fact,
+		// so they are streamed once complete.
+		err = eng.stream(findings)
 	}
 	if err != nil {
 		return Result{}, err
 	}
+	cacheHits := 0
+	var warnings []string
+	if cache != nil {
+		if err := cache.save(); err != nil {
+			warnings = append(warnings, fmt.Sprintf("write scan cache: %v", err))
+		}
+		cacheHits = cache.hits
+	}
 
 	filtered := make([]model.Finding, 0, len(findings))
 	for _, f := range findings {
@@ -126,31 +233,38 @@ func Run(ctx context.Context, opts Options) (Result, error) {
 		filtered = append(filtered, f)
 	}
 
+	if mode == "all-history" || mode == "deep-history" {
+		filtered = groupByFingerprint(filtered)
+	}
+
 	if opts.Validate {
 		for i := range filtered {
-			now := time.Now().UTC()
-			filtered[i].Validation.ValidatedAt = &now
-			c := connector.FindByRuleID(filtered[i].RuleID)
-			if c == nil {
-				filtered[i].Validation.Status = "unknown"
-				filtered[i].Validation.Method = "no-connector"
+			// Streamed findings were validated when they were emitted.
+			if filtered[i].Suppression != nil || filtered[i].Validation.ValidatedAt != nil {
 				continue
 			}
-			status, details := connector.ValidateWithConnector(ctx, c, filtered[i].RawSecret)
-			filtered[i].Validation.Status = status
-			filtered[i].Validation.Method = c.Name()
-			filtered[i].Validation.Details = details
-			filtered[i].Confidence = confidenceFromValidation(filtered[i].Confidence, status)
+			validateFinding(ctx, &filtered[i], eng.scanTime(), opts.OfflineValidation, nil)
 		}
 	}
 
 	sort.Slice(filtered, func(i, j int) bool {
-		if filtered[i].Location.File == filtered[j].Location.File {
-			return filtered[i].Location.LineStart < filtered[j].Location.LineStart
+		a, b := filtered[i].Location, filtered[j].Location
+		if a.File != b.File {
+			return a.File < b.File
+		}
+		if a.Address != b.Address {
+			return a.Address < b.Address
 		}
-		return filtered[i].Location.File < filtered[j].Location.File
+		return a.LineStart < b.LineStart
 	})
 
+	enforced := make([]model.Finding, 0, len(filtered))
+	for _, f := range filtered {
+		if f.Suppression == nil {
+			enforced = append(enforced, f)
+		}
+	}
+
 	report := model.FindingReport{
 		Schema:   "https://secrethawk.dev/schemas/finding-v1.json",
 		Findings: filtered,
@@ -161,12 +275,16 @@ func Run(ctx context.Context, opts Options) (Result, error) {
 			ScanTarget:       opts.Target,
 			ScanMode:         mode,
 			FilesScanned:     filesScanned,
+			CacheHits:        cacheHits,
+			SkippedPaths:     skipped,
 			DurationMS:       time.Since(start).Milliseconds(),
 			RulesLoaded:      len(allRules),
+			RulesVersion:     rules.CatalogVersion,
 			PolicyFile:       opts.PolicyPath,
-			SeverityCounts:   countBySeverity(filtered),
-			ValidationCounts: countByValidation(filtered),
-			ConfidenceCounts: countByConfidence(filtered),
+			Suppressed:       len(filtered) - len(enforced),
+			SeverityCounts:   countBySeverity(enforced),
+			ValidationCounts: countByValidation(enforced),
+			ConfidenceCounts: countByConfidence(enforced),
 		},
 	}
 
@@ -176,7 +294,7 @@ func Run(ctx context.Context, opts Options) (Result, error) {
 		if err != nil {
 			return Result{}, err
 		}
-		for _, f := range filtered {
+		for _, f := range enforced {
 			if opts.FailOnActive && f.Validation.Status != "active" {
 				continue
 			}
@@ -187,11 +305,126 @@ func Run(ctx context.Context, opts Options) (Result, error) {
 		}
 	}
 
-	return Result{Report: report, ShouldFail: shouldFail, ScannedMode: mode}, nil
+	return Result{Report: report, ShouldFail: shouldFail, ScannedMode: mode, Warnings: warnings}, nil
 }
 
-func scanWorkingTree(ctx context.Context, opts Options, allRules []rules.Rule, policy config.Policy, threshold string) ([]model.Finding, int, error) {
-	files, err := discoverFiles(ctx, opts)
+// connectorResult is the outcome of one provider check.
+type connectorResult struct {
+	status  string
+	details map[string]any
+}
+
+// validateFinding checks whether the finding's secret is live using the
+// connector registered for its rule. Findings that already failed their
+// offline check are not sent to the provider. When results is not nil,
+// outcomes are reused for findings with the same rule and secret. scanTime
+// is the clock offline checks judge expiry against.
+func validateFinding(ctx context.Context, f *model.Finding, scanTime time.Time, offlineOnly bool, results map[string]connectorResult) {
+	now := time.Now().UTC()
+	f.Validation.ValidatedAt = &now
+	checkOffline(f, scanTime)
+	if f.Validation.Status == offline.StatusInvalidFormat {
+		return
+	}
+	if offlineOnly {
+		if f.Validation.Method == "" {
+			f.Validation.Method = "no-offline-check"
+		}
+		return
+	}
+	c := connector.FindByRuleID(f.RuleID)
+	if c == nil {
+		f.Validation.Status = "unknown"
+		f.Validation.Method = "no-connector"
+		return
+	}
+	key := f.RuleID + "|" + f.RawSecret
+	r, ok := results[key]
+	if !ok {
+		r.status, r.details = connector.ValidateWithConnector(ctx, c, f.RawSecret)
+		if results != nil {
+			results[key] = r
+		}
+	}
+	status, details := r.status, r.details
+	f.Validation.Status = status
+	f.Validation.Method = c.Name()
+	if f.Validation.Details == nil {
+		f.Validation.Details = map[string]any{}
+	}
+	for k, v := range details {
+		f.Validation.Details[k] = v
+	}
+	f.Confidence = confidenceFromValidation(f.Confidence, status)
+}
+
+// engine holds the loaded rules, policy and per-scan lookup structures. It is
+// built once per Run and shared read-only by all workers.
+type engine struct {
+	rules        []rules.Rule
+	keywords     *rules.KeywordIndex
+	policy       config.Policy
+	threshold    string
+	maxSizeBytes int64
+	// entropyRule is the enabled generic entropy rule, or nil.
+	entropyRule *rules.Rule
+	// terraformRule is the enabled Terraform sensitive-attribute rule, or nil.
+	terraformRule *rules.Rule
+	// kubernetesRule is the enabled Kubernetes Secret value rule, or nil.
+	kubernetesRule *rules.Rule
+	// contextLines holds each rule's widest guard window; maxContext is the
+	// widest across all rules.
+	contextLines []int
+	maxContext   int
+	// emit, when set, receives findings as soon as they are collected.
+	emit func([]model.Finding) error
+	// now is the scan time used for time-dependent checks such as JWT expiry.
+	now time.Time
+}
+
+func (e *engine) scanTime() time.Time {
+	if e.now.IsZero() {
+		return time.Now()
+	}
+	return e.now
+}
+
+// stream hands a batch of freshly collected findings to the RunStream
+// callback. It may update the batch in place, e.g. with validation results.
+func (e *engine) stream(batch []model.Finding) error {
+	if e.emit == nil || len(batch) == 0 {
+		return nil
+	}
+	return e.emit(batch)
+}
+
+func newEngine(allRules []rules.Rule, policy config.Policy, threshold string, maxSizeBytes int64) *engine {
+	e := &engine{
+		rules:        allRules,
+		keywords:     rules.NewKeywordIndex(allRules),
+		policy:       policy,
+		threshold:    threshold,
+		maxSizeBytes: maxSizeBytes,
+	}
+	e.contextLines = make([]int, len(allRules))
+	for i := range allRules {
+		if allRules[i].Type == rules.TypeEntropy && e.entropyRule == nil {
+			e.entropyRule = &allRules[i]
+		}
+		if allRules[i].Type == rules.TypeTerraform && e.terraformRule == nil {
+			e.terraformRule = &allRules[i]
+		}
+		if allRules[i].Type == rules.TypeKubernetes && e.kubernetesRule == nil {
+			e.kubernetesRule = &allRules[i]
+		}
+		e.contextLines[i] = rules.ContextLines(allRules[i])
+		e.maxContext = max(e.maxContext, e.contextLines[i])
+	}
+	return e
+}
+
+func scanWorkingTree(ctx context.Context, opts Options, eng *engine, cache *scanCache, skipped map[string]int) ([]model.Finding, int, error) {
+	files, err := discoverFiles(ctx, opts, skipped)
 	if err != nil {
 		return nil, 0, err
 	}
@@ -199,10 +432,19 @@ func scanWorkingTree(ctx context.Context, opts Options, allRules []rules.Rule, p
 		return []model.Finding{}, 0, nil
 	}
 
-	maxSizeBytes := int64(opts.MaxTargetMegabytes) * 1024 * 1024
+	// Workers stop on cancel, and res is drained on early return so none of
+	// them stays blocked sending a result.
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
 	jobs := make(chan string)
 	res := make(chan []model.Finding)
 	errCh := make(chan error, 1)
+	fail := func(err error) ([]model.Finding, int, error) {
+		cancel()
+		for range res {
+		}
+		return nil, 0, err
+	}
 
 	workerCount := opts.Threads
 	if workerCount > len(files) {
@@ -218,7 +460,7 @@ func scanWorkingTree(ctx context.Context, opts Options, allRules []rules.Rule, p
 		go func() {
 			defer wg.Done()
 			for path := range jobs {
-				fnds, err := scanFile(path, allRules, policy, threshold, maxSizeBytes)
+				fnds, err := eng.scanFile(path, cache)
 				if err != nil {
 					select {
 					case errCh <- err:
@@ -226,14 +468,23 @@ func scanWorkingTree(ctx context.Context, opts Options, allRules []rules.Rule, p
 					}
 					return
 				}
-				res <- fnds
+				select {
+				case res <- fnds:
+				case <-ctx.Done():
+					return
+				}
 			}
 		}()
 	}
 
 	go func() {
+	feed:
 		for _, f := range files {
-			jobs <- f
+			select {
+			case jobs <- f:
+			case <-ctx.Done():
+				break feed
+			}
 		}
 		close(jobs)
 		wg.Wait()
@@ -245,18 +496,46 @@ func scanWorkingTree(ctx context.Context, opts Options, allRules []rules.Rule, p
 		select {
 		case err := <-errCh:
 			if err != nil {
-				return nil, 0, err
+				return fail(err)
 			}
 		case batch, ok := <-res:
 			if !ok {
 				return collected, len(files), nil
 			}
+			if err := eng.stream(batch); err != nil {
+				return fail(err)
+			}
 			collected = append(collected, batch...)
 		}
 	}
 }
 
-func discoverFiles(ctx context.Context, opts Options) ([]string, error) {
+func (e *engine) scanStdin(opts Options) ([]model.Finding, int, error) {
+	if opts.Stdin == nil {
+		return nil, 0, fmt.Errorf("stdin scan requested but no input reader provided")
+	}
+	name := filepath.ToSlash(opts.StdinFilename)
+	if name == "" {
+		name = "stdin"
+	}
+	if shouldExcludePath(name, e.policy) {
+		return []model.Finding{}, 0, nil
+	}
+	data, err := io.ReadAll(io.LimitReader(opts.Stdin, e.maxSizeBytes+1))
+	if err != nil {
+		return nil, 0, fmt.Errorf("read stdin: %w", err)
+	}
+	if int64(len(data)) > e.maxSizeBytes {
+		return nil, 0, fmt.Errorf("stdin exceeds --max-target-megabytes (%d MB)", opts.MaxTargetMegabytes)
+	}
+	findings, err := e.scanData(name, data)
+	if err != nil {
+		return nil, 0, err
+	}
+	return findings, 1, nil
+}
+
+func discoverFiles(ctx context.Context, opts Options, skipped map[string]int) ([]string, error) {
 	if opts.Staged {
 		return gitNameOnly(ctx, "diff", "--cached", "--name-only", "--diff-filter=ACMR")
 	}
@@ -266,15 +545,23 @@ func discoverFiles(ctx context.Context, opts Options) ([]string, error) {
 	}
 
 	files := make([]string, 0)
+	ignorer := newPathIgnorer(opts.Target, !opts.NoGitignore, skipped)
 	err := filepath.WalkDir(opts.Target, func(path string, d fs.DirEntry, walkErr error) error {
 		if walkErr != nil {
 			return walkErr
 		}
 		if d.IsDir() {
 			name := d.Name()
-			if name == ".git" || name == "node_modules" || name == "vendor" {
+			if name == ".git" || name == "node_modules" || name == "vendor" || isCacheDir(path, opts.CacheDir) {
 				return filepath.SkipDir
 			}
+			if path != opts.Target && ignorer.ignored(path, true) {
+				return filepath.SkipDir
+			}
+			ignorer.enter(path)
+			return nil
+		}
+		if ignorer.ignored(path, false) {
 			return nil
 		}
 		files = append(files, path)
@@ -287,16 +574,11 @@ func discoverFiles(ctx context.Context, opts Options) ([]string, error) {
 }
 
 func gitNameOnly(ctx context.Context, args ...string) ([]string, error) {
-	cmd := exec.CommandContext(ctx, "git", args...)
-	out, err := cmd.Output()
+	out, err := gitOutput(ctx, args...)
 	if err != nil {
-		var ee *exec.ExitError
-		if errors.As(err, &ee) && len(ee.Stderr) > 0 {
-			return nil, fmt.Errorf("git %s failed: %s", strings.Join(args, " "), strings.TrimSpace(string(ee.Stderr)))
-		}
-		return nil, fmt.Errorf("git %s failed: %w", strings.Join(args, " "), err)
+		return nil, err
 	}
-	lines := strings.Split(strings.ReplaceAll(string(out), "\r\n", "\n"), "\n")
+	lines := strings.Split(strings.ReplaceAll(out, "\r\n", "\n"), "\n")
 	files := make([]string, 0, len(lines))
 	for _, l := range lines {
 		l = strings.TrimSpace(l)
@@ -308,9 +590,22 @@ func gitNameOnly(ctx context.Context, args ...string) ([]string, error) {
 	return files, nil
 }
 
-func scanFile(path string, allRules []rules.Rule, policy config.Policy, threshold string, maxSizeBytes int64) ([]model.Finding, error) {
+func gitOutput(ctx context.Context, args ...string) (string, error) {
+	cmd := exec.CommandContext(ctx, "git", args...)
+	out, err := cmd.Output()
+	if err != nil {
+		var ee *exec.ExitError
+		if errors.As(err, &ee) && len(ee.Stderr) > 0 {
+			return "", fmt.Errorf("git %s failed: %s", strings.Join(args, " "), strings.TrimSpace(string(ee.Stderr)))
+		}
+		return "", fmt.Errorf("git %s failed: %w", strings.Join(args, " "), err)
+	}
+	return string(out), nil
+}
+
+func (e *engine) scanFile(path string, cache *scanCache) ([]model.Finding, error) {
 	norm := filepath.ToSlash(path)
-	if shouldExcludePath(norm, policy) {
+	if shouldExcludePath(norm, e.policy) {
 		return nil, nil
 	}
 	info, err := os.Stat(path)
@@ -320,42 +615,132 @@ func scanFile(path string, allRules []rules.Rule, policy config.Policy, threshol
 		}
 		return nil, err
 	}
-	if info.Size() > maxSizeBytes {
+	if info.Size() > e.maxSizeBytes {
 		return nil, nil
 	}
 	data, err := os.ReadFile(path)
 	if err != nil {
 		return nil, err
 	}
+	if cache == nil {
+		return e.scanData(norm, data)
+	}
+	hash := contentHash(data)
+	if cached, ok := cache.lookup(norm, data, hash); ok {
+		return cached, nil
+	}
+	findings, err := e.scanData(norm, data)
+	if err != nil {
+		return nil, err
+	}
+	if !e.clockDependent(findings) {
+		cache.store(norm, data, hash, findings)
+	}
+	return findings, nil
+}
+
+// clockDependent reports whether any finding comes from a rule whose
+// severity and details depend on the scan time, such as JWT expiry. Such
+// files are rescanned instead of cached.
+func (e *engine) clockDependent(findings []model.Finding) bool {
+	for _, f := range findings {
+		for _, r := range e.rules {
+			if r.ID == f.RuleID && r.Type == rules.TypeJWT {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func (e *engine) scanData(path string, data []byte) ([]model.Finding, error) {
+	if archiveKind(path, data) != "" {
+		return e.scanArchive(path, data)
+	}
 	if isBinary(data) {
 		return nil, nil
 	}
-
-	findings := make([]model.Finding, 0)
-	scanner := bufio.NewScanner(strings.NewReader(string(data)))
-	lineNo := 0
-	for scanner.Scan() {
-		lineNo++
-		line := scanner.Text()
-		lineFindings := scanLine(norm, line, lineNo, allRules, policy, threshold)
-		findings = append(findings, lineFindings...)
-	}
-	if err := scanner.Err(); err != nil {
-		return nil, err
+	if isTerraformFile(path, data) {
+		if findings, ok := e.scanTerraform(path, data); ok {
+			return findings, nil
+		}
+	}
+	if isNotebook(path) {
+		if findings, ok := e.scanNotebook(path, data); ok {
+			return findings, nil
+		}
 	}
+	return e.scanContent(path, string(data))
+}
 
-	generic := scanHighEntropy(norm, string(data), threshold, policy)
-	findings = append(findings, generic...)
+func (e *engine) scanContent(path string, text string) ([]model.Finding, error) {
+	multiline := e.scanMultiline(path, text)
+	findings := append(make([]model.Finding, 0, len(multiline)), multiline...)
+	lines := strings.Split(strings.ReplaceAll(text, "\r\n", "\n"), "\n")
+	for i := range lines {
+		findings = append(findings, e.scanLine(path, lines, i)...)
+	}
+	parsed := append(e.scanJWTs(path, lines), e.scanConnectionStrings(path, lines)...)
+	findings = append(findings, parsed...)
+
+	decoded, fired := e.scanDecoded(path, text)
+	findings = append(findings, dropRepeated(decoded, findings)...)
+	// Secret manifest values are reported at their object address instead
+	// of as plain line matches.
+	k8s, covered := e.scanKubernetes(path, text)
+	findings = append(dropOverlapping(findings, covered), k8s...)
+	for line, values := range covered {
+		fired[line] = append(fired[line], values...)
+	}
+	// Entropy matches on the parts of a parsed token or password are the
+	// secret itself.
+	for line, secrets := range secretsByLine(parsed) {
+		fired[line] = append(fired[line], secrets...)
+	}
+
+	// Key/value findings only add what specific rules missed, and both
+	// suppress entropy noise on the same value.
+	kv := dropOverlapping(dropOverlapping(e.scanKeyValues(path, text), covered), secretsByLine(findings))
+	findings = append(findings, kv...)
+	for line, secrets := range secretsByLine(kv) {
+		fired[line] = append(fired[line], secrets...)
+	}
+
+	generic := e.scanHighEntropy(path, text)
+	findings = append(findings, dropOverlapping(dropCoveredLines(generic, multiline), fired)...)
+	e.applyInlineSuppressions(text, findings)
 	return findings, nil
 }
 
-func scanLine(path string, line string, lineNo int, allRules []rules.Rule, policy config.Policy, threshold string) []model.Finding {
+// scanLine runs the line-based regex rules over lines[idx]. Rules whose guards
+// use context_lines see the surrounding lines, and their keyword prefilter is
+// applied to the same window.
+func (e *engine) scanLine(path string, lines []string, idx int) []model.Finding {
+	line := lines[idx]
+	lineNo := idx + 1
 	findings := make([]model.Finding, 0)
-	for _, rule := range allRules {
-		if !severity.MeetsOrAbove(rule.Severity, threshold) {
+	candidates := e.keywords.Candidates(line)
+	var windowCandidates []bool
+	for i, rule := range e.rules {
+		if rule.Type != rules.TypeRegex || rule.Detection.Multiline {
 			continue
 		}
-		if !rules.MatchRule(rule, line) {
+		if !candidates[i] {
+			if e.contextLines[i] == 0 {
+				continue
+			}
+			if windowCandidates == nil {
+				lo, hi := max(idx-e.maxContext, 0), min(idx+e.maxContext+1, len(lines))
+				windowCandidates = e.keywords.Candidates(strings.Join(lines[lo:hi], "\n"))
+			}
+			if !windowCandidates[i] {
+				continue
+			}
+		}
+		if !severity.MeetsOrAbove(rule.Severity, e.threshold) {
+			continue
+		}
+		if !rules.MatchRuleInWindow(rule, lines, idx) {
 			continue
 		}
 
@@ -364,7 +749,7 @@ func scanLine(path string, line string, lineNo int, allRules []rules.Rule, polic
 			continue
 		}
 		secret := extractSecret(line, idx)
-		if isAllowlisted(policy, path, rule.ID, secret, line, nil) {
+		if isAllowlisted(e.policy, path, rule.ID, secret, line, nil) {
 			continue
 		}
 		findings = append(findings, makeFinding(path, lineNo, secret, line, rule.ID, rule.Name, rule.Severity, rule.Category, nil))
@@ -372,30 +757,6 @@ func scanLine(path string, line string, lineNo int, allRules []rules.Rule, polic
 	return findings
 }
 
-func scanHighEntropy(path string, text string, threshold string, policy config.Policy) []model.Finding {
-	if !severity.MeetsOrAbove("medium", threshold) {
-		return nil
-	}
-	tokenRE := regexp.MustCompile(`[A-Za-z0-9_\-+/=]{20,}`)
-	findings := make([]model.Finding, 0)
-	lines := strings.Split(strings.ReplaceAll(text, "\r\n", "\n"), "\n")
-	for i, line := range lines {
-		for _, token := range tokenRE.FindAllString(line, -1) {
-			ent := entropy(token)
-			if ent < 4.5 {
-				continue
-			}
-			if isAllowlisted(policy, path, "generic-high-entropy", token, line, nil) {
-				continue
-			}
-			f := makeFinding(path, i+1, token, line, "generic-high-entropy", "Generic High-Entropy String", "medium", "generic", nil)
-			f.Match.Entropy = ent
-			findings = append(findings, f)
-		}
-	}
-	return findings
-}
-
 func makeFinding(path string, lineNo int, secret string, line string, ruleID string, ruleName string, sev string, category string, commit *string) model.Finding {
 	colStart := strings.Index(line, secret)
 	if colStart < 0 {
@@ -407,12 +768,9 @@ func makeFinding(path string, lineNo int, secret string, line string, ruleID str
 		colEnd = colStart
 	}
 	lineHash := baseline.ComputeLineHash(line)
-	idBase := ruleID + "|" + path + "|" + fmt.Sprintf("%d", lineNo) + "|" + lineHash
-	sum := sha1.Sum([]byte(idBase))
-	id := "f-" + hex.EncodeToString(sum[:8])
 
 	return model.Finding{
-		ID:         id,
+		ID:         findingID(ruleID, path, lineNo, lineHash, commit),
 		RuleID:     ruleID,
 		RuleName:   ruleName,
 		Severity:   sev,
@@ -445,6 +803,17 @@ func makeFinding(path string, lineNo int, secret string, line string, ruleID str
 	}
 }
 
+// findingID is stable for the same rule, location and line content. History
+// findings also include the commit so re-added lines stay distinct.
+func findingID(ruleID string, path string, lineNo int, lineHash string, commit *string) string {
+	idBase := ruleID + "|" + path + "|" + fmt.Sprintf("%d", lineNo) + "|" + lineHash
+	if commit != nil {
+		idBase += "|" + *commit
+	}
+	sum := sha1.Sum([]byte(idBase))
+	return "f-" + hex.EncodeToString(sum[:8])
+}
+
 func baseConfidence(ruleID string) string {
 	if ruleID == "generic-high-entropy" {
 		return "medium"
@@ -461,7 +830,7 @@ func confidenceFromValidation(current string, validationStatus string) string {
 			return "medium"
 		}
 		return current
-	case "error":
+	case "error", offline.StatusInvalidFormat:
 		return "low"
 	default:
 		if current == "" {
@@ -533,11 +902,16 @@ func isAllowlisted(policy config.Policy, path string, ruleID string, secret stri
 			return true
 		}
 	}
-	if commit != nil {
-		for _, c := range policy.Allowlist.Commits {
-			if c.SHA == *commit {
-				return true
-			}
+	if commit != nil && commitAllowlisted(policy, *commit) {
+		return true
+	}
+	return false
+}
+
+func commitAllowlisted(policy config.Policy, sha string) bool {
+	for _, c := range policy.Allowlist.Commits {
+		if c.SHA == sha {
+			return true
 		}
 	}
 	return false
@@ -566,82 +940,6 @@ func extractSecret(line string, submatchIdx []int) string {
 	return strings.TrimSpace(line[submatchIdx[0]:submatchIdx[1]])
 }
 
-func scanAllHistory(ctx context.Context, allRules []rules.Rule, policy config.Policy, threshold string) ([]model.Finding, int, error) {
-	revs, err := gitNameOnly(ctx, "rev-list", "--all")
-	if err != nil {
-		return nil, 0, err
-	}
-	if len(revs) == 0 {
-		return []model.Finding{}, 0, nil
-	}
-
-	findings := make([]model.Finding, 0)
-	fileSet := map[string]struct{}{}
-	for _, rule := range allRules {
-		if !severity.MeetsOrAbove(rule.Severity, threshold) {
-			continue
-		}
-
-		args := []string{"grep", "-nI", "-E", "-e", rule.Detection.Regex}
-		args = append(args, revs...)
-		cmd := exec.CommandContext(ctx, "git", args...)
-		out, err := cmd.Output()
-		if err != nil {
-			var ee *exec.ExitError
-			if errors.As(err, &ee) {
-				if ee.ExitCode() == 1 {
-					continue
-				}
-				return nil, 0, fmt.Errorf("git grep history failed: %s", strings.TrimSpace(string(ee.Stderr)))
-			}
-			return nil, 0, err
-		}
-		lines := strings.Split(strings.ReplaceAll(string(out), "\r\n", "\n"), "\n")
-		for _, l := range lines {
-			if strings.TrimSpace(l) == "" {
-				continue
-			}
-			parts := strings.SplitN(l, ":", 4)
-			if len(parts) < 4 {
-				continue
-			}
-			commit := parts[0]
-			path := parts[1]
-			lineNo := parseInt(parts[2])
-			content := parts[3]
-			if lineNo < 1 {
-				lineNo = 1
-			}
-			if !rules.MatchRule(rule, content) {
-				continue
-			}
-			idx := rule.Regex.FindStringSubmatchIndex(content)
-			if len(idx) == 0 {
-				continue
-			}
-			secret := extractSecret(content, idx)
-			if isAllowlisted(policy, path, rule.ID, secret, content, &commit) {
-				continue
-			}
-			fileSet[path] = struct{}{}
-			findings = append(findings, makeFinding(path, lineNo, secret, content, rule.ID, rule.Name, rule.Severity, rule.Category, &commit))
-		}
-	}
-
-	return findings, len(fileSet), nil
-}
-
-func parseInt(s string) int {
-	n := 0
-	for _, r := range s {
-		if r < '0' || r > '9' {
-			return n
-		}
-		n = n*10 + int(r-'0')
-	}
-	return n
-}
-
 func countBySeverity(findings []model.Finding) map[string]int {
 	out := map[string]int{"critical": 0, "high": 0, "medium": 0, "low": 0}
 	for _, f := range findings {
@@ -655,7 +953,7 @@ func countBySeverity(findings []model.Finding) map[string]int {
 }
 
 func countByValidation(findings []model.Finding) map[string]int {
-	out := map[string]int{"active": 0, "inactive": 0, "unknown": 0, "error": 0}
+	out := map[string]int{"active": 0, "inactive": 0, "unknown": 0, "error": 0, offline.StatusInvalidFormat: 0}
 	for _, f := range findings {
 		status := strings.TrimSpace(f.Validation.Status)
 		if status == "" {
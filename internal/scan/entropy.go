@@ -0,0 +1,128 @@
+package scan
+
+import (
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/bmatcuk/doublestar/v4"
+	"github.com/peter941221/secrethawk/internal/config"
+	"github.com/peter941221/secrethawk/internal/model"
+	"github.com/peter941221/secrethawk/internal/severity"
+)
+
+var (
+	entropyTokenRE = regexp.MustCompile(`[A-Za-z0-9_\-+/=]+`)
+	hexCharsetRE   = regexp.MustCompile(`^[0-9a-fA-F]+$`)
+	hexDigestRE    = regexp.MustCompile(`^(?:[0-9a-fA-F]{40}|[0-9a-fA-F]{64}|[0-9a-fA-F]{96}|[0-9a-fA-F]{128})$`)
+	md5DigestRE    = regexp.MustCompile(`^[0-9a-fA-F]{32}$`)
+	md5ContextRE   = regexp.MustCompile(`(?i)md5`)
+	digestPrefixRE = regexp.MustCompile(`(?i)(?:md5|sha1|sha224|sha256|sha384|sha512)[:=-]$`)
+	uuidRE         = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+	sriHashRE      = regexp.MustCompile(`^sha(?:256|384|512)-[A-Za-z0-9+/]+={0,2}$`)
+)
+
+// lockfiles hold integrity hashes and resolved checksums, never credentials.
+var lockfiles = map[string]struct{}{
+	"package-lock.json":   {},
+	"npm-shrinkwrap.json": {},
+	"yarn.lock":           {},
+	"pnpm-lock.yaml":      {},
+	"bun.lock":            {},
+	"go.sum":              {},
+	"Cargo.lock":          {},
+	"Gemfile.lock":        {},
+	"composer.lock":       {},
+	"poetry.lock":         {},
+	"Pipfile.lock":        {},
+	"uv.lock":             {},
+	"packages.lock.json":  {},
+}
+
+// scanHighEntropy flags random-looking tokens using the enabled entropy rule.
+// Hex and base64/alphanumeric tokens are scored against separate length and
+// threshold settings resolved for path from the policy.
+func (e *engine) scanHighEntropy(path string, text string) []model.Finding {
+	rule := e.entropyRule
+	if rule == nil || !severity.MeetsOrAbove(rule.Severity, e.threshold) {
+		return nil
+	}
+	if isLockfile(path) {
+		return nil
+	}
+	hexCfg, b64Cfg := entropySettingsFor(e.policy, path)
+	findings := make([]model.Finding, 0)
+	lines := strings.Split(strings.ReplaceAll(text, "\r\n", "\n"), "\n")
+	for i, line := range lines {
+		for _, token := range entropyTokenRE.FindAllString(line, -1) {
+			cfg := b64Cfg
+			if hexCharsetRE.MatchString(token) {
+				cfg = hexCfg
+			}
+			if len(token) < cfg.MinLength || knownNonSecret(token, line) {
+				continue
+			}
+			ent := entropy(token)
+			if ent < cfg.Threshold {
+				continue
+			}
+			if isAllowlisted(e.policy, path, rule.ID, token, line, nil) {
+				continue
+			}
+			f := makeFinding(path, i+1, token, line, rule.ID, rule.Name, rule.Severity, rule.Category, nil)
+			f.Match.Entropy = ent
+			findings = append(findings, f)
+		}
+	}
+	return findings
+}
+
+// entropySettingsFor returns the hex and base64 settings for path, applying
+// every matching per-path override in order.
+func entropySettingsFor(policy config.Policy, path string) (config.EntropyCharset, config.EntropyCharset) {
+	defaults := config.DefaultPolicy().Scan.Entropy
+	hexCfg := mergeCharset(defaults.Hex, policy.Scan.Entropy.Hex)
+	b64Cfg := mergeCharset(defaults.Base64, policy.Scan.Entropy.Base64)
+	for _, o := range policy.Scan.Entropy.Paths {
+		m, err := doublestar.PathMatch(o.Pattern, path)
+		if err != nil || !m {
+			continue
+		}
+		hexCfg = mergeCharset(hexCfg, o.Hex)
+		b64Cfg = mergeCharset(b64Cfg, o.Base64)
+	}
+	return hexCfg, b64Cfg
+}
+
+func mergeCharset(base config.EntropyCharset, override config.EntropyCharset) config.EntropyCharset {
+	if override.MinLength > 0 {
+		base.MinLength = override.MinLength
+	}
+	if override.Threshold > 0 {
+		base.Threshold = override.Threshold
+	}
+	return base
+}
+
+// knownNonSecret matches high-entropy shapes that are identifiers or digests
+// rather than credentials: git object IDs and SHA digests, UUIDs, SRI hashes
+// and any token written after an algorithm prefix such as "sha256:". A
+// 32-digit hex token is only taken for an MD5 digest when the line mentions
+// md5, since many API keys share that shape.
+func knownNonSecret(token string, line string) bool {
+	if hexDigestRE.MatchString(token) || uuidRE.MatchString(token) || sriHashRE.MatchString(token) {
+		return true
+	}
+	if md5DigestRE.MatchString(token) && md5ContextRE.MatchString(line) {
+		return true
+	}
+	if i := strings.Index(line, token); i > 0 && digestPrefixRE.MatchString(line[:i]) {
+		return true
+	}
+	return false
+}
+
+func isLockfile(path string) bool {
+	_, ok := lockfiles[filepath.Base(path)]
+	return ok
+}
@@ -0,0 +1,104 @@
+package scan
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+)
+
+func TestRunEntropyUsesCharsetSettingsAndExclusions(t *testing.T) {
+	tmp := t.TempDir()
+	repo := filepath.Join(tmp, "repo")
+	files := map[string]string{
+		"app/hex.env":       "SIGNING_KEY=9f3a0c7e51b2d846e1f3a5c7b9d02f48\n",
+		"app/shapes.txt":    "commit 95cb0bfd2977c761298d9624e4b4d4c72a39974a\nid 8f14e45f-ceea-467f-a0e6-5c2b1a7d9e3b\n",
+		"Dockerfile":        "FROM alpine@sha256:4058b7cc79116877333dac0f93b9e78400e1786e39e13cfa6b7540ba3647b239\n",
+		"app/sri.json":      `{"integrity": "sha512-pKvURIxJVi2CgRXROh/M6pJ/UrTVRZKX+LQ+QtqJI4vBNibkPcs43bCCSIkn7JBPtCBXRDmD6IWFF51QVRr+Yg=="}` + "\n",
+		"package-lock.json": `{"resolved": "abcdefghijklmnopqrstuvwxyz1234567890ABCD"}` + "\n",
+		"fixtures/a.txt":    "x = \"abcdefghijklmnopqrstuvwxyz1234567890ABCD\"\n",
+	}
+	for name, content := range files {
+		p := filepath.Join(repo, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	policy := `version: "1"
+scan:
+  entropy:
+    paths:
+      - pattern: "**/fixtures/**"
+        base64: {threshold: 6}
+`
+	policyPath := filepath.Join(tmp, "policy.yaml")
+	if err := os.WriteFile(policyPath, []byte(policy), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	run := func(rulesPath string) []string {
+		t.Helper()
+		res, err := Run(context.Background(), Options{
+			Target:             repo,
+			PolicyPath:         policyPath,
+			RulesPath:          rulesPath,
+			BaselinePath:       filepath.Join(tmp, "baseline.json"),
+			Severity:           "medium",
+			MaxTargetMegabytes: 5,
+			Version:            "test",
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		files := make([]string, 0)
+		for _, f := range res.Report.Findings {
+			if f.RuleID == "generic-high-entropy" {
+				rel, _ := filepath.Rel(repo, f.Location.File)
+				files = append(files, filepath.ToSlash(rel))
+			}
+		}
+		sort.Strings(files)
+		return files
+	}
+
+	got := run("")
+	if len(got) != 1 || got[0] != "app/hex.env" {
+		t.Fatalf("expected only the hex secret, got %v", got)
+	}
+
+	disable := filepath.Join(tmp, "disable.yaml")
+	if err := os.WriteFile(disable, []byte("rules:\n  - id: generic-high-entropy\n    disabled: true\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if got := run(disable); len(got) != 0 {
+		t.Fatalf("expected disabled entropy rule to report nothing, got %v", got)
+	}
+}
+
+func TestKnownNonSecretDigests(t *testing.T) {
+	sha256 := "4058b7cc79116877333dac0f93b9e78400e1786e39e13cfa6b7540ba3647b239"
+	sha512 := "9562771c5971387a76f6819de8c0a867d1f376db25f57dcbb7138a8c848651482118d4cd31fa0d2452efd34be8b1ce7ac4929ecd14bf289b397e949a274b2887"
+	cases := []struct {
+		name  string
+		token string
+		line  string
+		want  bool
+	}{
+		{"sha256", sha256, sha256 + "  app.tar.gz", true},
+		{"sha512", sha512, sha512 + "  app.tar.gz", true},
+		{"md5", "3c627e2c08c5706d54797efbb50c7822", "MD5 (app.tar.gz) = 3c627e2c08c5706d54797efbb50c7822", true},
+		{"uppercase sha1", "B17217FBD751CB4AAD2529171AAA66F8E1DB4092", "SHA1: B17217FBD751CB4AAD2529171AAA66F8E1DB4092", true},
+		{"sha256 prefix", sha256[:48], "digest: sha256:" + sha256[:48], true},
+		{"sha512 prefix", sha512[:48], "sha512-" + sha512[:48], true},
+		{"bare 32 hex", "9f3a0c7e51b2d846e1f3a5c7b9d02f48", "SIGNING_KEY=9f3a0c7e51b2d846e1f3a5c7b9d02f48", false},
+	}
+	for _, c := range cases {
+		if got := knownNonSecret(c.token, c.line); got != c.want {
+			t.Errorf("%s: knownNonSecret(%q) = %v, want %v", c.name, c.token, got, c.want)
+		}
+	}
+}
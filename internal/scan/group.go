@@ -0,0 +1,91 @@
+package scan
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"sort"
+	"strings"
+
+	"github.com/peter941221/secrethawk/internal/model"
+)
+
+// fingerprint identifies a secret independent of where it was found.
+func fingerprint(f model.Finding) string {
+	sum := sha256.Sum256([]byte(f.RuleID + "\x00" + f.RawSecret))
+	return hex.EncodeToString(sum[:])
+}
+
+// groupByFingerprint collapses history findings of the same secret into one
+// finding per fingerprint. The earliest occurrence becomes the finding's
+// location and the rest are listed in Occurrences. A group is only suppressed
+// when every occurrence is.
+func groupByFingerprint(findings []model.Finding) []model.Finding {
+	groups := map[string][]model.Finding{}
+	order := make([]string, 0)
+	for _, f := range findings {
+		fp := fingerprint(f)
+		if _, ok := groups[fp]; !ok {
+			order = append(order, fp)
+		}
+		groups[fp] = append(groups[fp], f)
+	}
+
+	out := make([]model.Finding, 0, len(order))
+	for _, fp := range order {
+		members := groups[fp]
+		sort.SliceStable(members, func(i, j int) bool {
+			return occursBefore(members[i].Location, members[j].Location)
+		})
+
+		g := members[0]
+		g.ID = "f-" + fp[:16]
+		g.Fingerprint = fp
+		g.Occurrences = make([]model.Occurrence, 0, len(members))
+		branches := map[string]struct{}{}
+		for _, m := range members {
+			occ := model.Occurrence{
+				File:        m.Location.File,
+				Address:     m.Location.Address,
+				LineStart:   m.Location.LineStart,
+				AuthorEmail: m.Location.AuthorEmail,
+				CommittedAt: m.Location.CommittedAt,
+				Source:      m.Location.Source,
+			}
+			if m.Location.Commit != nil {
+				occ.Commit = *m.Location.Commit
+			}
+			g.Occurrences = append(g.Occurrences, occ)
+			for _, b := range strings.Split(m.Location.Branch, ",") {
+				if b != "" {
+					branches[b] = struct{}{}
+				}
+			}
+			if m.Suppression == nil {
+				g.Suppression = nil
+			}
+		}
+		g.FirstSeenCommit = g.Occurrences[0].Commit
+		g.LastSeenCommit = g.Occurrences[len(g.Occurrences)-1].Commit
+		names := make([]string, 0, len(branches))
+		for b := range branches {
+			names = append(names, b)
+		}
+		sort.Strings(names)
+		g.Location.Branch = strings.Join(names, ",")
+		out = append(out, g)
+	}
+	return out
+}
+
+func occursBefore(a, b model.Location) bool {
+	if a.CommittedAt != nil && b.CommittedAt != nil && !a.CommittedAt.Equal(*b.CommittedAt) {
+		return a.CommittedAt.Before(*b.CommittedAt)
+	}
+	if a.File != b.File {
+		return a.File < b.File
+	}
+	if a.Address != b.Address {
+		return a.Address < b.Address
+	}
+	return a.LineStart < b.LineStart
+}
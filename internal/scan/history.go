@@ -0,0 +1,382 @@
+package scan
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/peter941221/secrethawk/internal/model"
+)
+
+// historyCommit is one commit to scan. Its added lines are taken from the diff
+// against Base when set, otherwise against its first parent.
+type historyCommit struct {
+	SHA         string
+	AuthorEmail string
+	CommittedAt time.Time
+	Base        string
+	// Source labels where a deep-history commit was found (see deep.go).
+	Source string
+}
+
+// addedHunk is a contiguous run of lines a commit added to a file. StartLine is
+// the 1-based line number of the first added line in the new file.
+type addedHunk struct {
+	Path      string
+	StartLine int
+	Lines     []string
+}
+
+// scanHistory walks every commit reachable from any ref of the repository at
+// repo and scans only the lines each commit added, so a finding is attributed
+// to the commit that introduced it. Merge commits are diffed against their
+// first parent, so content written while resolving a merge is attributed to
+// the merge. Stashes are left to the deep-history scan.
+func (e *engine) scanHistory(ctx context.Context, repo string, threads int) ([]model.Finding, int, error) {
+	commits, err := listHistoryCommits(ctx, repo, "--exclude=refs/stash", "--all")
+	if err != nil {
+		return nil, 0, err
+	}
+	findings, filesScanned, err := e.scanCommits(ctx, repo, threads, commits)
+	if err != nil {
+		return nil, 0, err
+	}
+	if err := annotateBranches(ctx, repo, findings); err != nil {
+		return nil, 0, err
+	}
+	return findings, filesScanned, nil
+}
+
+// scanHistoryRange scans the commits in a revision range such as
+// "main..feature" or "v1.2.0..HEAD".
+func (e *engine) scanHistoryRange(ctx context.Context, repo string, threads int, revRange string) ([]model.Finding, int, error) {
+	// git log would parse a leading dash as an option.
+	if strings.HasPrefix(revRange, "-") {
+		return nil, 0, fmt.Errorf("invalid revision range %q", revRange)
+	}
+	commits, err := listHistoryCommits(ctx, repo, revRange)
+	if err != nil {
+		return nil, 0, err
+	}
+	findings, filesScanned, err := e.scanCommits(ctx, repo, threads, commits)
+	if err != nil {
+		return nil, 0, err
+	}
+	if err := annotateBranches(ctx, repo, findings); err != nil {
+		return nil, 0, err
+	}
+	return findings, filesScanned, nil
+}
+
+// scanCommits scans the added lines of each commit in parallel.
+func (e *engine) scanCommits(ctx context.Context, repo string, threads int, commits []historyCommit) ([]model.Finding, int, error) {
+	if len(commits) == 0 {
+		return []model.Finding{}, 0, nil
+	}
+
+	type commitResult struct {
+		findings []model.Finding
+		paths    []string
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	jobs := make(chan historyCommit)
+	res := make(chan commitResult)
+	errCh := make(chan error, 1)
+	fail := func(err error) ([]model.Finding, int, error) {
+		cancel()
+		for range res {
+		}
+		return nil, 0, err
+	}
+
+	workerCount := threads
+	if workerCount > len(commits) {
+		workerCount = len(commits)
+	}
+	if workerCount < 1 {
+		workerCount = 1
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < workerCount; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for c := range jobs {
+				fnds, paths, err := e.scanCommit(ctx, repo, c)
+				if err != nil {
+					select {
+					case errCh <- err:
+					default:
+					}
+					return
+				}
+				select {
+				case res <- commitResult{findings: fnds, paths: paths}:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}()
+	}
+
+	go func() {
+	feed:
+		for _, c := range commits {
+			select {
+			case jobs <- c:
+			case <-ctx.Done():
+				break feed
+			}
+		}
+		close(jobs)
+		wg.Wait()
+		close(res)
+	}()
+
+	collected := make([]model.Finding, 0)
+	fileSet := map[string]struct{}{}
+	for done := false; !done; {
+		select {
+		case err := <-errCh:
+			if err != nil {
+				return fail(err)
+			}
+		case r, ok := <-res:
+			if !ok {
+				done = true
+				break
+			}
+			if err := e.stream(r.findings); err != nil {
+				return fail(err)
+			}
+			collected = append(collected, r.findings...)
+			for _, p := range r.paths {
+				fileSet[p] = struct{}{}
+			}
+		}
+	}
+
+	return collected, len(fileSet), nil
+}
+
+// scanCommit runs the engine over the hunks added by a single commit.
+func (e *engine) scanCommit(ctx context.Context, repo string, c historyCommit) ([]model.Finding, []string, error) {
+	if commitAllowlisted(e.policy, c.SHA) {
+		return nil, nil, nil
+	}
+	args := []string{"-C", repo, "-c", "core.quotePath=false", "diff-tree", "-p", "-r", "-M",
+		"--no-commit-id", "--no-color", "--no-ext-diff", "--no-textconv", "--unified=0"}
+	if c.Base != "" {
+		args = append(args, c.Base, c.SHA)
+	} else {
+		args = append(args, "--root", c.SHA)
+	}
+	out, err := gitOutput(ctx, args...)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	hunks := parseAddedHunks(out)
+	findings := make([]model.Finding, 0)
+	paths := make([]string, 0)
+	seen := map[string]struct{}{}
+	for _, h := range hunks {
+		if shouldExcludePath(h.Path, e.policy) {
+			continue
+		}
+		if _, ok := seen[h.Path]; !ok {
+			seen[h.Path] = struct{}{}
+			paths = append(paths, h.Path)
+		}
+		fnds, err := e.scanContent(h.Path, strings.Join(h.Lines, "\n"))
+		if err != nil {
+			return nil, nil, err
+		}
+		for _, f := range fnds {
+			sha := c.SHA
+			committedAt := c.CommittedAt
+			f.Location.LineStart += h.StartLine - 1
+			f.Location.LineEnd += h.StartLine - 1
+			f.Location.Commit = &sha
+			f.Location.AuthorEmail = c.AuthorEmail
+			f.Location.CommittedAt = &committedAt
+			f.Location.Source = c.Source
+			if f.Suppression != nil {
+				f.Suppression.Line += h.StartLine - 1
+			}
+			f.ID = findingID(f.RuleID, locationKey(f.Location), f.Location.LineStart, f.LineHash, &sha)
+			findings = append(findings, f)
+		}
+	}
+	return findings, paths, nil
+}
+
+// listHistoryCommits lists the commits selected by revs, which are passed to
+// git log as-is. Merge commits carry their first parent as Base.
+func listHistoryCommits(ctx context.Context, repo string, revs ...string) ([]historyCommit, error) {
+	args := append([]string{"-C", repo, "log", "--format=" + commitLogFormat}, revs...)
+	out, err := gitOutput(ctx, args...)
+	if err != nil {
+		return nil, err
+	}
+	commits := make([]historyCommit, 0)
+	for _, c := range parseCommitLog(out) {
+		// diff-tree prints nothing for a merge on its own; its first
+		// parent is the branch the merge was made on.
+		if len(c.Parents) > 1 {
+			c.Base = c.Parents[0]
+		}
+		commits = append(commits, c.historyCommit)
+	}
+	return commits, nil
+}
+
+// commitLogFormat is the git log --format parsed by parseCommitLog.
+const commitLogFormat = "%H%x1f%P%x1f%ae%x1f%cI"
+
+type loggedCommit struct {
+	historyCommit
+	Parents []string
+}
+
+func parseCommitLog(out string) []loggedCommit {
+	commits := make([]loggedCommit, 0)
+	for _, l := range strings.Split(strings.ReplaceAll(out, "\r\n", "\n"), "\n") {
+		parts := strings.Split(strings.TrimSpace(l), "\x1f")
+		if len(parts) != 4 {
+			continue
+		}
+		committedAt, err := time.Parse(time.RFC3339, parts[3])
+		if err != nil {
+			continue
+		}
+		commits = append(commits, loggedCommit{
+			historyCommit: historyCommit{SHA: parts[0], AuthorEmail: parts[2], CommittedAt: committedAt.UTC()},
+			Parents:       strings.Fields(parts[1]),
+		})
+	}
+	return commits
+}
+
+// parseAddedHunks extracts the added lines of a zero-context unified diff.
+// Deleted files, binary files and removed lines produce no hunks. Added lines
+// are counted from each hunk header, so content such as "++ x" (added as
+// "+++ x") is never mistaken for a file header.
+func parseAddedHunks(diff string) []addedHunk {
+	hunks := make([]addedHunk, 0)
+	path := ""
+	remaining := 0
+	var cur *addedHunk
+	flush := func() {
+		if cur != nil && len(cur.Lines) > 0 {
+			hunks = append(hunks, *cur)
+		}
+		cur = nil
+	}
+	for _, l := range strings.Split(strings.ReplaceAll(diff, "\r\n", "\n"), "\n") {
+		if remaining > 0 && strings.HasPrefix(l, "+") {
+			remaining--
+			if cur != nil {
+				cur.Lines = append(cur.Lines, strings.TrimPrefix(l, "+"))
+			}
+			continue
+		}
+		switch {
+		case strings.HasPrefix(l, "diff --git "):
+			flush()
+			path, remaining = "", 0
+		case strings.HasPrefix(l, "+++ "):
+			flush()
+			path = diffPath(strings.TrimPrefix(l, "+++ "))
+		case strings.HasPrefix(l, "@@ "):
+			flush()
+			start, count, ok := hunkNewRange(l)
+			if !ok {
+				remaining = 0
+				continue
+			}
+			remaining = count
+			if path != "" {
+				cur = &addedHunk{Path: path, StartLine: start}
+			}
+		}
+	}
+	flush()
+	return hunks
+}
+
+// diffPath returns the new-file path from a "+++" header, or "" for deletions.
+// git ends the header with a tab when the path contains a space.
+func diffPath(s string) string {
+	s = strings.TrimSuffix(s, "\t")
+	if strings.HasPrefix(s, "\"") {
+		if unq, err := strconv.Unquote(s); err == nil {
+			s = unq
+		}
+	}
+	if s == "/dev/null" {
+		return ""
+	}
+	return strings.TrimPrefix(s, "b/")
+}
+
+// hunkNewRange parses the new-file start line and line count from
+// "@@ -a,b +c,d @@". A missing count means one line.
+func hunkNewRange(header string) (int, int, bool) {
+	fields := strings.Fields(header)
+	if len(fields) < 3 || !strings.HasPrefix(fields[2], "+") {
+		return 0, 0, false
+	}
+	spec, countSpec, hasCount := strings.Cut(strings.TrimPrefix(fields[2], "+"), ",")
+	n, err := strconv.Atoi(spec)
+	if err != nil {
+		return 0, 0, false
+	}
+	count := 1
+	if hasCount {
+		if count, err = strconv.Atoi(countSpec); err != nil {
+			return 0, 0, false
+		}
+	}
+	if n < 1 {
+		n = 1
+	}
+	return n, count, true
+}
+
+// annotateBranches records the local and remote branches that contain each
+// finding's commit. Lookups are cached per commit.
+func annotateBranches(ctx context.Context, repo string, findings []model.Finding) error {
+	cache := map[string]string{}
+	for i := range findings {
+		commit := findings[i].Location.Commit
+		if commit == nil {
+			continue
+		}
+		branch, ok := cache[*commit]
+		if !ok {
+			out, err := gitOutput(ctx, "-C", repo, "for-each-ref", "--contains", *commit, "--format=%(refname:short)", "refs/heads", "refs/remotes")
+			if err != nil {
+				return err
+			}
+			names := make([]string, 0)
+			for _, l := range strings.Split(out, "\n") {
+				l = strings.TrimSpace(l)
+				if l == "" || strings.HasSuffix(l, "/HEAD") {
+					continue
+				}
+				names = append(names, l)
+			}
+			branch = strings.Join(names, ",")
+			cache[*commit] = branch
+		}
+		findings[i].Location.Branch = branch
+	}
+	return nil
+}
@@ -0,0 +1,292 @@
+package scan
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRunAllHistoryAttributesIntroducingCommit(t *testing.T) {
+	tmp := t.TempDir()
+	t.Setenv("HOME", tmp)
+	t.Setenv("XDG_CONFIG_HOME", filepath.Join(tmp, "xdg"))
+	t.Setenv("GIT_CONFIG_NOSYSTEM", "1")
+	repo := filepath.Join(tmp, "repo")
+	if err := os.MkdirAll(repo, 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	git(t, repo, "init", "-b", "main")
+	writeAndCommit(t, repo, "app.py", "print('hello')\n", "alice@example.com", "initial")
+	writeAndCommit(t, repo, "app.py", fmt.Sprintf("print('hello')\n\naws_key = %q\n", testAWSKey()), "bob@example.com", "add key")
+	introduced := strings.TrimSpace(git(t, repo, "rev-parse", "HEAD"))
+	git(t, repo, "branch", "feature")
+	// Later commits that keep or remove the line must not be blamed for it.
+	writeAndCommit(t, repo, "app.py", fmt.Sprintf("import os\nprint('hello')\n\naws_key = %q\n", testAWSKey()), "carol@example.com", "touch")
+	writeAndCommit(t, repo, "app.py", "print('hello')\n", "carol@example.com", "remove key")
+
+	res, err := Run(context.Background(), Options{
+		Target:             repo,
+		AllHistory:         true,
+		PolicyPath:         filepath.Join(tmp, "policy.yaml"),
+		BaselinePath:       filepath.Join(tmp, "baseline.json"),
+		Severity:           "high",
+		MaxTargetMegabytes: 5,
+		Version:            "test",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(res.Report.Findings) != 1 {
+		t.Fatalf("expected 1 finding, got %+v", res.Report.Findings)
+	}
+	f := res.Report.Findings[0]
+	loc := f.Location
+	if f.RuleID != "aws-access-key-id" || loc.File != "app.py" || loc.LineStart != 3 {
+		t.Fatalf("unexpected finding: %+v", f)
+	}
+	if loc.Commit == nil || *loc.Commit != introduced {
+		t.Fatalf("expected commit %s, got %+v", introduced, loc.Commit)
+	}
+	if loc.AuthorEmail != "bob@example.com" || loc.CommittedAt == nil {
+		t.Fatalf("expected author and date, got %+v", loc)
+	}
+	if loc.Branch != "feature,main" {
+		t.Fatalf("expected containing branches, got %q", loc.Branch)
+	}
+}
+
+func TestParseAddedHunks(t *testing.T) {
+	diff := strings.Join([]string{
+		"diff --git a/a.txt b/a.txt",
+		"--- a/a.txt",
+		"+++ b/a.txt",
+		"@@ -2 +2,2 @@",
+		"-old",
+		"+new one",
+		"+new two",
+		"@@ -9,0 +11,2 @@ ctx",
+		"+tail",
+		"+++ not a header",
+		"diff --git a/gone.txt b/gone.txt",
+		"--- a/gone.txt",
+		"+++ /dev/null",
+		"@@ -1 +0,0 @@",
+		"-bye",
+		"diff --git a/my file.py b/my file.py",
+		"--- a/my file.py\t",
+		"+++ b/my file.py\t",
+		"@@ -0,0 +1 @@",
+		"+spaced",
+	}, "\n")
+
+	hunks := parseAddedHunks(diff)
+	if len(hunks) != 3 {
+		t.Fatalf("expected 2 hunks, got %+v", hunks)
+	}
+	if hunks[0].Path != "a.txt" || hunks[0].StartLine != 2 || len(hunks[0].Lines) != 2 {
+		t.Fatalf("unexpected first hunk: %+v", hunks[0])
+	}
+	if hunks[1].Path != "a.txt" || hunks[1].StartLine != 11 || len(hunks[1].Lines) != 2 || hunks[1].Lines[1] != "++ not a header" {
+		t.Fatalf("unexpected second hunk: %+v", hunks[1])
+	}
+	if hunks[2].Path != "my file.py" || hunks[2].StartLine != 1 {
+		t.Fatalf("unexpected spaced-path hunk: %+v", hunks[2])
+	}
+}
+
+func TestRunAllHistoryScansEvilMerge(t *testing.T) {
+	tmp := t.TempDir()
+	t.Setenv("HOME", tmp)
+	t.Setenv("XDG_CONFIG_HOME", filepath.Join(tmp, "xdg"))
+	t.Setenv("GIT_CONFIG_NOSYSTEM", "1")
+	repo := filepath.Join(tmp, "repo")
+	if err := os.MkdirAll(repo, 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	git(t, repo, "init", "-b", "main")
+	writeAndCommit(t, repo, "app.py", "print('hello')\n", "alice@example.com", "initial")
+	git(t, repo, "checkout", "-q", "-b", "side")
+	writeAndCommit(t, repo, "README.md", "docs\n", "alice@example.com", "docs")
+	git(t, repo, "checkout", "-q", "main")
+	writeAndCommit(t, repo, "main.txt", "main\n", "alice@example.com", "main")
+	// The secret is added while resolving the merge, so no parent contains it.
+	git(t, repo, "-c", "user.name=test", "-c", "user.email=bob@example.com", "merge", "-q", "--no-ff", "--no-commit", "side")
+	writeAndCommit(t, repo, "app.py", fmt.Sprintf("print('hello')\naws_key = %q\n", testAWSKey()), "bob@example.com", "merge side")
+	merge := strings.TrimSpace(git(t, repo, "rev-parse", "HEAD"))
+
+	res, err := Run(context.Background(), Options{
+		Target:             repo,
+		AllHistory:         true,
+		PolicyPath:         filepath.Join(tmp, "policy.yaml"),
+		BaselinePath:       filepath.Join(tmp, "baseline.json"),
+		Severity:           "high",
+		MaxTargetMegabytes: 5,
+		Version:            "test",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res.Report.Findings) != 1 {
+		t.Fatalf("expected 1 finding, got %+v", res.Report.Findings)
+	}
+	loc := res.Report.Findings[0].Location
+	if loc.File != "app.py" || loc.LineStart != 2 || loc.Commit == nil || *loc.Commit != merge {
+		t.Fatalf("expected finding in merge %s, got %+v", merge, loc)
+	}
+}
+
+func writeAndCommit(t *testing.T, repo string, name string, content string, email string, msg string) {
+	t.Helper()
+	if err := os.WriteFile(filepath.Join(repo, name), []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	git(t, repo, "add", "-A")
+	git(t, repo, "-c", "user.name=test", "-c", "user.email="+email, "commit", "-q", "-m", msg)
+}
+
+func git(t *testing.T, dir string, args ...string) string {
+	t.Helper()
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("git %v failed: %v output=%s", args, err, string(out))
+	}
+	return string(out)
+}
+
+func TestRunAllHistoryGroupsOccurrencesByFingerprint(t *testing.T) {
+	tmp := t.TempDir()
+	t.Setenv("HOME", tmp)
+	t.Setenv("XDG_CONFIG_HOME", filepath.Join(tmp, "xdg"))
+	t.Setenv("GIT_CONFIG_NOSYSTEM", "1")
+	repo := filepath.Join(tmp, "repo")
+	if err := os.MkdirAll(repo, 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	line := fmt.Sprintf("aws_key = %q\n", testAWSKey())
+	git(t, repo, "init", "-b", "main")
+	writeAndCommit(t, repo, "a.py", line, "alice@example.com", "first")
+	first := strings.TrimSpace(git(t, repo, "rev-parse", "HEAD"))
+	writeAndCommit(t, repo, "b.py", line, "alice@example.com", "copy")
+	writeAndCommit(t, repo, "c.py", "x = 1\n"+line, "bob@example.com", "copy again")
+	last := strings.TrimSpace(git(t, repo, "rev-parse", "HEAD"))
+
+	res, err := Run(context.Background(), Options{
+		Target:             repo,
+		AllHistory:         true,
+		PolicyPath:         filepath.Join(tmp, "policy.yaml"),
+		BaselinePath:       filepath.Join(tmp, "baseline.json"),
+		Severity:           "high",
+		MaxTargetMegabytes: 5,
+		Version:            "test",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(res.Report.Findings) != 1 {
+		t.Fatalf("expected 1 grouped finding, got %d", len(res.Report.Findings))
+	}
+	f := res.Report.Findings[0]
+	if len(f.Occurrences) != 3 {
+		t.Fatalf("expected 3 occurrences, got %+v", f.Occurrences)
+	}
+	if f.Fingerprint == "" || f.FirstSeenCommit != first || f.LastSeenCommit != last {
+		t.Fatalf("unexpected grouping metadata: fingerprint=%q first=%s last=%s", f.Fingerprint, f.FirstSeenCommit, f.LastSeenCommit)
+	}
+	if f.Location.File != "a.py" || f.Occurrences[2].File != "c.py" || f.Occurrences[2].LineStart != 2 {
+		t.Fatalf("unexpected occurrences: %+v", f.Occurrences)
+	}
+}
+
+func TestRunDeepHistoryLabelsSources(t *testing.T) {
+	tmp := t.TempDir()
+	t.Setenv("HOME", tmp)
+	t.Setenv("XDG_CONFIG_HOME", filepath.Join(tmp, "xdg"))
+	t.Setenv("GIT_CONFIG_NOSYSTEM", "1")
+	repo := filepath.Join(tmp, "repo")
+	if err := os.MkdirAll(repo, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	key := func(suffix string) string {
+		return strings.TrimSuffix(testAWSKey(), "F7") + suffix
+	}
+
+	git(t, repo, "init", "-b", "main")
+	writeAndCommit(t, repo, "app.py", "print('hello')\n", "alice@example.com", "initial")
+
+	// A commit reset away survives only in the HEAD and branch reflogs.
+	writeAndCommit(t, repo, "app.py", fmt.Sprintf("aws_key = %q\n", key("F2")), "alice@example.com", "oops")
+	git(t, repo, "reset", "-q", "--hard", "HEAD~1")
+
+	// A stashed working tree change.
+	if err := os.WriteFile(filepath.Join(repo, "app.py"), []byte(fmt.Sprintf("aws_key = %q\n", key("F3"))), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	git(t, repo, "-c", "user.name=test", "-c", "user.email=alice@example.com", "stash", "-q")
+
+	// A commit object no ref or reflog points to.
+	blob := filepath.Join(tmp, "orphan.py")
+	if err := os.WriteFile(blob, []byte(fmt.Sprintf("aws_key = %q\n", key("F5"))), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	blobSHA := strings.TrimSpace(git(t, repo, "hash-object", "-w", blob))
+	treeCmd := exec.Command("git", "mktree")
+	treeCmd.Dir = repo
+	treeCmd.Stdin = strings.NewReader("100644 blob " + blobSHA + "\torphan.py\n")
+	treeOut, err := treeCmd.Output()
+	if err != nil {
+		t.Fatal(err)
+	}
+	git(t, repo, "-c", "user.name=test", "-c", "user.email=alice@example.com", "commit-tree", "-m", "orphan", strings.TrimSpace(string(treeOut)))
+
+	// A blob written but never committed.
+	dangling := filepath.Join(tmp, "dangling.txt")
+	if err := os.WriteFile(dangling, []byte(fmt.Sprintf("aws_key = %q\n", key("F6"))), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	git(t, repo, "hash-object", "-w", dangling)
+
+	res, err := Run(context.Background(), Options{
+		Target:             repo,
+		DeepHistory:        true,
+		PolicyPath:         filepath.Join(tmp, "policy.yaml"),
+		BaselinePath:       filepath.Join(tmp, "baseline.json"),
+		Severity:           "high",
+		MaxTargetMegabytes: 5,
+		Version:            "test",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sources := map[string]string{}
+	for _, f := range res.Report.Findings {
+		if f.RuleID == "aws-access-key-id" {
+			sources[f.RawSecret] = f.Location.Source
+		}
+	}
+	want := map[string]string{
+		key("F2"): "reflog",
+		key("F3"): "stash",
+		key("F5"): "unreachable",
+		key("F6"): "dangling-blob",
+	}
+	for secret, source := range want {
+		if sources[secret] != source {
+			t.Fatalf("expected %s to be found via %s, got sources %v", secret, source, sources)
+		}
+	}
+	if res.Report.Metadata.ScanMode != "deep-history" {
+		t.Fatalf("unexpected scan mode %q", res.Report.Metadata.ScanMode)
+	}
+}
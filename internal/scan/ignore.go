@@ -0,0 +1,225 @@
+package scan
+
+import (
+	"bufio"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/bmatcuk/doublestar/v4"
+)
+
+const (
+	ignoreSourceGitignore        = "gitignore"
+	ignoreSourceInfoExclude      = "info-exclude"
+	ignoreSourceGlobalExcludes   = "global-excludes"
+	ignoreSourceSecrethawkignore = "secrethawkignore"
+
+	secrethawkIgnoreFile = ".secrethawkignore"
+)
+
+type ignoreRule struct {
+	// pattern is a doublestar glob relative to base.
+	pattern string
+	base    string
+	negate  bool
+	dirOnly bool
+	source  string
+}
+
+// ignoreMatcher applies gitignore semantics: later rules win, "!" re-includes,
+// patterns without a slash match at any depth, and rules only apply below the
+// directory of the file that declared them.
+type ignoreMatcher struct {
+	rules []ignoreRule
+}
+
+func (m *ignoreMatcher) loadFile(file string, base string, source string) {
+	f, err := os.Open(file)
+	if err != nil {
+		return
+	}
+	defer f.Close()
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		if r, ok := parseIgnoreLine(sc.Text(), base, source); ok {
+			m.rules = append(m.rules, r)
+		}
+	}
+}
+
+func (m *ignoreMatcher) match(rel string, isDir bool) (bool, string) {
+	for i := len(m.rules) - 1; i >= 0; i-- {
+		r := m.rules[i]
+		if r.dirOnly && !isDir {
+			continue
+		}
+		sub := rel
+		if r.base != "" {
+			if !strings.HasPrefix(rel, r.base+"/") {
+				continue
+			}
+			sub = rel[len(r.base)+1:]
+		}
+		if ok, _ := doublestar.Match(r.pattern, sub); ok {
+			return !r.negate, r.source
+		}
+	}
+	return false, ""
+}
+
+func parseIgnoreLine(line string, base string, source string) (ignoreRule, bool) {
+	line = strings.TrimSuffix(line, "\r")
+	for strings.HasSuffix(line, " ") && !strings.HasSuffix(line, `\ `) {
+		line = line[:len(line)-1]
+	}
+	if line == "" || strings.HasPrefix(line, "#") {
+		return ignoreRule{}, false
+	}
+	r := ignoreRule{base: base, source: source}
+	switch {
+	case strings.HasPrefix(line, "!"):
+		r.negate = true
+		line = line[1:]
+	case strings.HasPrefix(line, `\!`), strings.HasPrefix(line, `\#`):
+		line = line[1:]
+	}
+	if strings.HasSuffix(line, "/") {
+		r.dirOnly = true
+		line = strings.TrimRight(line, "/")
+	}
+	anchored := strings.Contains(line, "/")
+	line = strings.TrimPrefix(line, "/")
+	if line == "" {
+		return ignoreRule{}, false
+	}
+	// gitignore has no brace expansion; keep braces literal for doublestar.
+	line = strings.NewReplacer("{", `\{`, "}", `\}`).Replace(line)
+	if !anchored {
+		line = "**/" + line
+	}
+	r.pattern = line
+	return r, true
+}
+
+// pathIgnorer tracks git and secrethawk ignore rules while walking a
+// directory tree and counts how many paths each source skipped.
+type pathIgnorer struct {
+	target     string
+	absTarget  string
+	root       string
+	git        *ignoreMatcher
+	secrethawk *ignoreMatcher
+	skipped    map[string]int
+}
+
+func newPathIgnorer(target string, useGitignore bool, skipped map[string]int) *pathIgnorer {
+	abs, err := filepath.Abs(target)
+	if err != nil {
+		abs = target
+	}
+	root := findRepoRoot(abs)
+	p := &pathIgnorer{target: target, absTarget: abs, root: root, secrethawk: &ignoreMatcher{}, skipped: skipped}
+	if useGitignore {
+		p.git = &ignoreMatcher{}
+		if global := globalExcludesFile(); global != "" {
+			p.git.loadFile(global, "", ignoreSourceGlobalExcludes)
+		}
+		p.git.loadFile(filepath.Join(root, ".git", "info", "exclude"), "", ignoreSourceInfoExclude)
+	}
+	// Ignore files between the repository root and the scan target still
+	// apply when only a subdirectory is scanned.
+	if rel, err := filepath.Rel(root, abs); err == nil && rel != "." {
+		dir := root
+		p.enter(dir)
+		for _, part := range strings.Split(filepath.ToSlash(rel), "/") {
+			dir = filepath.Join(dir, part)
+			if dir != abs {
+				p.enter(dir)
+			}
+		}
+	}
+	return p
+}
+
+// enter loads ignore files declared in dir.
+func (p *pathIgnorer) enter(dir string) {
+	base := p.rel(dir)
+	if base == "." {
+		base = ""
+	}
+	if p.git != nil {
+		p.git.loadFile(filepath.Join(dir, ".gitignore"), base, ignoreSourceGitignore)
+	}
+	p.secrethawk.loadFile(filepath.Join(dir, secrethawkIgnoreFile), base, ignoreSourceSecrethawkignore)
+}
+
+func (p *pathIgnorer) ignored(path string, isDir bool) bool {
+	rel := p.rel(path)
+	if rel == "." || strings.HasPrefix(rel, "../") {
+		return false
+	}
+	if ok, source := p.secrethawk.match(rel, isDir); ok {
+		p.skipped[source]++
+		return true
+	}
+	if p.git != nil {
+		if ok, source := p.git.match(rel, isDir); ok {
+			p.skipped[source]++
+			return true
+		}
+	}
+	return false
+}
+
+func (p *pathIgnorer) rel(path string) string {
+	abs := path
+	if !filepath.IsAbs(path) {
+		if r, err := filepath.Rel(p.target, path); err == nil {
+			abs = filepath.Join(p.absTarget, r)
+		}
+	}
+	rel, err := filepath.Rel(p.root, abs)
+	if err != nil {
+		return filepath.ToSlash(path)
+	}
+	return filepath.ToSlash(rel)
+}
+
+func findRepoRoot(dir string) string {
+	for cur := dir; ; {
+		if _, err := os.Stat(filepath.Join(cur, ".git")); err == nil {
+			return cur
+		}
+		parent := filepath.Dir(cur)
+		if parent == cur {
+			return dir
+		}
+		cur = parent
+	}
+}
+
+func globalExcludesFile() string {
+	out, err := exec.Command("git", "config", "--get", "core.excludesFile").Output()
+	file := strings.TrimSpace(string(out))
+	if err != nil || file == "" {
+		if xdg := os.Getenv("XDG_CONFIG_HOME"); xdg != "" {
+			return filepath.Join(xdg, "git", "ignore")
+		}
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return ""
+		}
+		return filepath.Join(home, ".config", "git", "ignore")
+	}
+	if strings.HasPrefix(file, "~/") {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return ""
+		}
+		file = path.Join(filepath.ToSlash(home), file[2:])
+	}
+	return filepath.FromSlash(file)
+}
@@ -0,0 +1,90 @@
+package scan
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+)
+
+func TestRunHonorsGitignoreAndSecrethawkignore(t *testing.T) {
+	tmp := t.TempDir()
+	repo := filepath.Join(tmp, "repo")
+	t.Setenv("HOME", tmp)
+	t.Setenv("XDG_CONFIG_HOME", filepath.Join(tmp, "xdg"))
+	t.Setenv("GIT_CONFIG_NOSYSTEM", "1")
+
+	secret := fmt.Sprintf("key = %q\n", testAWSKey())
+	files := map[string]string{
+		".git/info/exclude": "secret-notes.txt\n",
+		".gitignore":        "# build output\nbuild/\n*.env\n!keep.env\n",
+		".secrethawkignore": "fixtures/\n",
+		"sub/.gitignore":    "local.py\n",
+		"build/out.py":      secret,
+		"a.env":             secret,
+		"keep.env":          secret,
+		"sub/local.py":      secret,
+		"sub/app.py":        secret,
+		"fixtures/x.py":     secret,
+		"app.log":           secret,
+		"secret-notes.txt":  secret,
+	}
+	for name, content := range files {
+		p := filepath.Join(repo, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	global := filepath.Join(tmp, "xdg", "git", "ignore")
+	if err := os.MkdirAll(filepath.Dir(global), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(global, []byte("*.log\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	run := func(noGitignore bool) Result {
+		res, err := Run(context.Background(), Options{
+			Target:             repo,
+			PolicyPath:         filepath.Join(tmp, "policy.yaml"),
+			BaselinePath:       filepath.Join(tmp, "baseline.json"),
+			Severity:           "critical",
+			MaxTargetMegabytes: 5,
+			Version:            "test",
+			NoGitignore:        noGitignore,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return res
+	}
+
+	res := run(false)
+	if got := findingFiles(repo, res); fmt.Sprint(got) != "[keep.env sub/app.py]" {
+		t.Fatalf("unexpected files with findings: %v", got)
+	}
+	want := map[string]int{"gitignore": 3, "info-exclude": 1, "global-excludes": 1, "secrethawkignore": 1}
+	if fmt.Sprint(res.Report.Metadata.SkippedPaths) != fmt.Sprint(want) {
+		t.Fatalf("unexpected skip counts: %v", res.Report.Metadata.SkippedPaths)
+	}
+
+	res = run(true)
+	if got := findingFiles(repo, res); len(got) != 7 {
+		t.Fatalf("expected only .secrethawkignore to apply, got %v", got)
+	}
+}
+
+func findingFiles(root string, res Result) []string {
+	out := []string{}
+	for _, f := range res.Report.Findings {
+		rel, _ := filepath.Rel(root, filepath.FromSlash(f.Location.File))
+		out = append(out, filepath.ToSlash(rel))
+	}
+	sort.Strings(out)
+	return out
+}
@@ -0,0 +1,420 @@
+package scan
+
+import (
+	"archive/tar"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/peter941221/secrethawk/internal/model"
+)
+
+const (
+	sourceImageLayer   = "image-layer"
+	sourceImageConfig  = "image-config"
+	sourceLowerLayer   = "present-in-lower-layer"
+	whiteoutPrefix     = ".wh."
+	whiteoutOpaqueName = ".wh..wh..opq"
+)
+
+// imageSource opens blobs from either a `docker save` tarball or an OCI
+// image layout directory.
+type imageSource interface {
+	open(name string) (io.ReadCloser, error)
+	has(name string) bool
+	Close() error
+}
+
+type imageManifest struct {
+	Config string
+	Layers []string
+}
+
+type imageConfig struct {
+	Config struct {
+		Env []string `json:"Env"`
+	} `json:"config"`
+	History []struct {
+		CreatedBy string `json:"created_by"`
+	} `json:"history"`
+	RootFS struct {
+		DiffIDs []string `json:"diff_ids"`
+	} `json:"rootfs"`
+}
+
+// layerChanges records what a layer removes or replaces from lower layers.
+type layerChanges struct {
+	written map[string]struct{}
+	deleted map[string]struct{}
+	opaque  []string
+}
+
+type layerEntryFindings struct {
+	layer    int
+	path     string
+	findings []model.Finding
+}
+
+func (e *engine) scanImage(imagePath string) ([]model.Finding, int, error) {
+	src, err := openImageSource(imagePath)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer src.Close()
+
+	manifests, err := readImageManifests(src)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	display := filepath.ToSlash(imagePath)
+	findings := make([]model.Finding, 0)
+	filesScanned := 0
+	for _, m := range manifests {
+		cfg := imageConfig{}
+		if m.Config != "" {
+			if err := readImageJSON(src, m.Config, &cfg); err != nil {
+				return nil, 0, err
+			}
+		}
+		cfgFindings, err := e.scanImageConfig(display, cfg)
+		if err != nil {
+			return nil, 0, err
+		}
+		findings = append(findings, cfgFindings...)
+
+		changes := make([]layerChanges, len(m.Layers))
+		entries := make([]layerEntryFindings, 0)
+		for i, layer := range m.Layers {
+			digest := layerDigest(layer, i, cfg)
+			changes[i] = layerChanges{written: map[string]struct{}{}, deleted: map[string]struct{}{}}
+			n, err := scanImageLayer(src, layer, func(name string, r io.Reader) error {
+				dir, base := path.Split(name)
+				switch {
+				case base == whiteoutOpaqueName:
+					changes[i].opaque = append(changes[i].opaque, strings.TrimSuffix(dir, "/"))
+					return nil
+				case strings.HasPrefix(base, whiteoutPrefix):
+					changes[i].deleted[dir+strings.TrimPrefix(base, whiteoutPrefix)] = struct{}{}
+					return nil
+				}
+				changes[i].written[name] = struct{}{}
+
+				entryPath := display + archiveSeparator + digest + "/" + name
+				if shouldExcludePath(entryPath, e.policy) {
+					return nil
+				}
+				data, err := io.ReadAll(io.LimitReader(r, e.maxSizeBytes+1))
+				if err != nil || int64(len(data)) > e.maxSizeBytes {
+					return nil
+				}
+				fnds, err := e.scanData(entryPath, data)
+				if err != nil {
+					return err
+				}
+				for k := range fnds {
+					fnds[k].Location.Layer = digest
+					fnds[k].Location.Source = sourceImageLayer
+				}
+				entries = append(entries, layerEntryFindings{layer: i, path: name, findings: fnds})
+				return nil
+			})
+			if err != nil {
+				return nil, 0, fmt.Errorf("scan image layer %s: %w", layer, err)
+			}
+			filesScanned += n
+		}
+
+		for _, ent := range entries {
+			if removedInUpperLayer(changes, ent.layer, ent.path) {
+				for k := range ent.findings {
+					ent.findings[k].Location.Source = sourceLowerLayer
+				}
+			}
+			findings = append(findings, ent.findings...)
+		}
+	}
+	return findings, filesScanned, nil
+}
+
+func (e *engine) scanImageConfig(display string, cfg imageConfig) ([]model.Finding, error) {
+	history := make([]string, 0, len(cfg.History))
+	for _, h := range cfg.History {
+		history = append(history, h.CreatedBy)
+	}
+	parts := []struct {
+		name  string
+		lines []string
+	}{
+		{"config/env", cfg.Config.Env},
+		{"config/history", history},
+	}
+
+	findings := make([]model.Finding, 0)
+	for _, p := range parts {
+		if len(p.lines) == 0 {
+			continue
+		}
+		fnds, err := e.scanContent(display+archiveSeparator+p.name, strings.Join(p.lines, "\n"))
+		if err != nil {
+			return nil, err
+		}
+		for k := range fnds {
+			fnds[k].Location.Source = sourceImageConfig
+		}
+		findings = append(findings, fnds...)
+	}
+	return findings, nil
+}
+
+// removedInUpperLayer reports whether a file from layer idx is deleted,
+// hidden by an opaque directory, or replaced by any later layer. Such files
+// are invisible in the running container but still shipped in the image.
+func removedInUpperLayer(changes []layerChanges, idx int, name string) bool {
+	for j := idx + 1; j < len(changes); j++ {
+		c := changes[j]
+		if _, ok := c.written[name]; ok {
+			return true
+		}
+		for p := name; p != "." && p != "/" && p != ""; p = path.Dir(p) {
+			if _, ok := c.deleted[p]; ok {
+				return true
+			}
+		}
+		for _, dir := range c.opaque {
+			if dir == "" || strings.HasPrefix(name, dir+"/") {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func scanImageLayer(src imageSource, layer string, visit func(string, io.Reader) error) (int, error) {
+	rc, err := src.open(layer)
+	if err != nil {
+		return 0, err
+	}
+	defer rc.Close()
+
+	br := bufio.NewReader(rc)
+	var r io.Reader = br
+	if head, _ := br.Peek(2); len(head) == 2 && head[0] == 0x1f && head[1] == 0x8b {
+		gz, err := gzip.NewReader(br)
+		if err != nil {
+			return 0, err
+		}
+		defer gz.Close()
+		r = gz
+	} else if head, _ := br.Peek(4); bytes.Equal(head, []byte{0x28, 0xb5, 0x2f, 0xfd}) {
+		return 0, fmt.Errorf("zstd-compressed layers are not supported")
+	}
+
+	count := 0
+	tr := tar.NewReader(r)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return count, nil
+		}
+		if err != nil {
+			return count, err
+		}
+		if hdr.Typeflag != tar.TypeReg {
+			continue
+		}
+		count++
+		if err := visit(strings.TrimPrefix(path.Clean("/"+hdr.Name), "/"), tr); err != nil {
+			return count, err
+		}
+	}
+}
+
+func layerDigest(layer string, idx int, cfg imageConfig) string {
+	parts := strings.Split(layer, "/")
+	if len(parts) == 3 && parts[0] == "blobs" {
+		return parts[1] + ":" + parts[2]
+	}
+	if idx < len(cfg.RootFS.DiffIDs) {
+		return cfg.RootFS.DiffIDs[idx]
+	}
+	return strings.TrimSuffix(layer, "/layer.tar")
+}
+
+func readImageManifests(src imageSource) ([]imageManifest, error) {
+	if src.has("manifest.json") {
+		var docker []struct {
+			Config string   `json:"Config"`
+			Layers []string `json:"Layers"`
+		}
+		if err := readImageJSON(src, "manifest.json", &docker); err != nil {
+			return nil, err
+		}
+		out := make([]imageManifest, 0, len(docker))
+		for _, d := range docker {
+			out = append(out, imageManifest{Config: d.Config, Layers: d.Layers})
+		}
+		return out, nil
+	}
+	if src.has("index.json") {
+		return readOCIIndex(src, "index.json", 0)
+	}
+	return nil, fmt.Errorf("not an image archive: manifest.json or index.json not found")
+}
+
+type ociDescriptor struct {
+	MediaType string `json:"mediaType"`
+	Digest    string `json:"digest"`
+}
+
+func readOCIIndex(src imageSource, name string, depth int) ([]imageManifest, error) {
+	if depth > maxArchiveDepth {
+		return nil, fmt.Errorf("image index nesting too deep")
+	}
+	var doc struct {
+		Manifests []ociDescriptor `json:"manifests"`
+		Config    ociDescriptor   `json:"config"`
+		Layers    []ociDescriptor `json:"layers"`
+	}
+	if err := readImageJSON(src, name, &doc); err != nil {
+		return nil, err
+	}
+	if len(doc.Manifests) == 0 {
+		m := imageManifest{Config: blobPath(doc.Config.Digest)}
+		for _, l := range doc.Layers {
+			m.Layers = append(m.Layers, blobPath(l.Digest))
+		}
+		return []imageManifest{m}, nil
+	}
+	out := make([]imageManifest, 0)
+	for _, d := range doc.Manifests {
+		nested, err := readOCIIndex(src, blobPath(d.Digest), depth+1)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, nested...)
+	}
+	return out, nil
+}
+
+func blobPath(digest string) string {
+	if digest == "" {
+		return ""
+	}
+	return "blobs/" + strings.Replace(digest, ":", "/", 1)
+}
+
+func readImageJSON(src imageSource, name string, v any) error {
+	rc, err := src.open(name)
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+	if err := json.NewDecoder(rc).Decode(v); err != nil {
+		return fmt.Errorf("parse image %s: %w", name, err)
+	}
+	return nil
+}
+
+func openImageSource(p string) (imageSource, error) {
+	info, err := os.Stat(p)
+	if err != nil {
+		return nil, err
+	}
+	if info.IsDir() {
+		return dirImageSource{root: p}, nil
+	}
+	return openTarImageSource(p)
+}
+
+type dirImageSource struct {
+	root string
+}
+
+func (d dirImageSource) open(name string) (io.ReadCloser, error) {
+	return os.Open(filepath.Join(d.root, filepath.FromSlash(name)))
+}
+
+func (d dirImageSource) has(name string) bool {
+	_, err := os.Stat(filepath.Join(d.root, filepath.FromSlash(name)))
+	return err == nil
+}
+
+func (d dirImageSource) Close() error { return nil }
+
+// tarImageSource indexes entry offsets once so blobs can be reopened as
+// section readers without extracting the image to disk.
+type tarImageSource struct {
+	file    *os.File
+	entries map[string]*io.SectionReader
+}
+
+type countingReader struct {
+	r io.Reader
+	n int64
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += int64(n)
+	return n, err
+}
+
+func openTarImageSource(p string) (*tarImageSource, error) {
+	f, err := os.Open(p)
+	if err != nil {
+		return nil, err
+	}
+	src := &tarImageSource{file: f, entries: map[string]*io.SectionReader{}}
+	links := map[string]string{}
+	cr := &countingReader{r: f}
+	tr := tar.NewReader(cr)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			f.Close()
+			return nil, fmt.Errorf("read image tar: %w", err)
+		}
+		name := strings.TrimPrefix(path.Clean("/"+hdr.Name), "/")
+		switch hdr.Typeflag {
+		case tar.TypeReg:
+			src.entries[name] = io.NewSectionReader(f, cr.n, hdr.Size)
+		case tar.TypeSymlink:
+			// Older `docker save` output links duplicate layers to one blob.
+			links[name] = strings.TrimPrefix(path.Clean(path.Join("/", path.Dir(name), hdr.Linkname)), "/")
+		}
+	}
+	for name, target := range links {
+		if sr, ok := src.entries[target]; ok {
+			src.entries[name] = sr
+		}
+	}
+	return src, nil
+}
+
+func (t *tarImageSource) open(name string) (io.ReadCloser, error) {
+	sr, ok := t.entries[strings.TrimPrefix(path.Clean("/"+name), "/")]
+	if !ok {
+		return nil, fmt.Errorf("image entry not found: %s", name)
+	}
+	return io.NopCloser(io.NewSectionReader(sr, 0, sr.Size())), nil
+}
+
+func (t *tarImageSource) has(name string) bool {
+	_, ok := t.entries[name]
+	return ok
+}
+
+func (t *tarImageSource) Close() error {
+	return t.file.Close()
+}
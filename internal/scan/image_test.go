@@ -0,0 +1,84 @@
+package scan
+
+import (
+	"archive/tar"
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRunScansImageLayersAndConfig(t *testing.T) {
+	tmp := t.TempDir()
+	key := testAWSKey()
+
+	lower := tarBytes(t, map[string]string{"app/.env": fmt.Sprintf("AWS_KEY: %s\n", key), "app/main.py": "print('ok')\n"})
+	upper := tarBytes(t, map[string]string{"app/.wh..env": ""})
+	cfg := fmt.Sprintf(`{"config":{"Env":["PATH=/usr/bin","DEPLOY_KEY %s"]},"history":[{"created_by":"COPY .env /app/.env"}],"rootfs":{"diff_ids":["sha256:lower","sha256:upper"]}}`, key)
+	manifest := `[{"Config":"config.json","Layers":["l1/layer.tar","l2/layer.tar"]}]`
+	image := tarBytes(t, map[string]string{
+		"manifest.json": manifest,
+		"config.json":   cfg,
+		"l1/layer.tar":  string(lower),
+		"l2/layer.tar":  string(upper),
+	})
+	imagePath := filepath.Join(tmp, "image.tar")
+	if err := os.WriteFile(imagePath, image, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	res, err := Run(context.Background(), Options{
+		ImageTar:           imagePath,
+		PolicyPath:         filepath.Join(tmp, "policy.yaml"),
+		BaselinePath:       filepath.Join(tmp, "baseline.json"),
+		Severity:           "critical",
+		MaxTargetMegabytes: 5,
+		Version:            "test",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.ScannedMode != "image" {
+		t.Fatalf("unexpected mode: %s", res.ScannedMode)
+	}
+
+	var layerHit, configHit bool
+	for _, f := range res.Report.Findings {
+		switch {
+		case strings.HasSuffix(f.Location.File, "!/sha256:lower/app/.env"):
+			layerHit = true
+			if f.Location.Layer != "sha256:lower" || f.Location.Source != "present-in-lower-layer" {
+				t.Fatalf("unexpected layer location: %+v", f.Location)
+			}
+		case strings.HasSuffix(f.Location.File, "!/config/env"):
+			configHit = true
+			if f.Location.LineStart != 2 || f.Location.Source != "image-config" {
+				t.Fatalf("unexpected config location: %+v", f.Location)
+			}
+		}
+	}
+	if !layerHit || !configHit {
+		t.Fatalf("expected layer and config findings, got %+v", res.Report.Findings)
+	}
+}
+
+func tarBytes(t *testing.T, files map[string]string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	tw := tar.NewWriter(&buf)
+	for name, content := range files {
+		if err := tw.WriteHeader(&tar.Header{Name: name, Mode: 0o644, Size: int64(len(content)), Typeflag: tar.TypeReg}); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := tw.Write([]byte(content)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
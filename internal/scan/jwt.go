@@ -0,0 +1,108 @@
+package scan
+
+import (
+	"strings"
+	"time"
+
+	"github.com/peter941221/secrethawk/internal/model"
+	"github.com/peter941221/secrethawk/internal/offline"
+	"github.com/peter941221/secrethawk/internal/rules"
+	"github.com/peter941221/secrethawk/internal/severity"
+)
+
+// scanJWTs runs the jwt rules over every line. Tokens whose header and claims
+// do not decode are skipped. The rest are reported with their claims in the
+// validation details and a severity derived from the claims.
+func (e *engine) scanJWTs(path string, lines []string) []model.Finding {
+	jwtRules := make([]int, 0)
+	for i, r := range e.rules {
+		if r.Type == rules.TypeJWT {
+			jwtRules = append(jwtRules, i)
+		}
+	}
+	if len(jwtRules) == 0 {
+		return nil
+	}
+
+	findings := make([]model.Finding, 0)
+	for idx, line := range lines {
+		candidates := e.keywords.Candidates(line)
+		for _, i := range jwtRules {
+			rule := e.rules[i]
+			if !candidates[i] || !rules.MatchContextWindow(rule, lines, idx, idx) {
+				continue
+			}
+			for _, m := range rule.Regex.FindAllStringSubmatchIndex(line, -1) {
+				secret := extractSecret(line, m)
+				header, claims, err := offline.DecodeJWT(secret)
+				if err != nil {
+					continue
+				}
+				sev, reason := jwtSeverity(rule, header, claims, e.scanTime())
+				if !severity.MeetsOrAbove(sev, e.threshold) {
+					continue
+				}
+				if isAllowlisted(e.policy, path, rule.ID, secret, line, nil) {
+					continue
+				}
+				f := makeFinding(path, idx+1, secret, line, rule.ID, rule.Name, sev, rule.Category, nil)
+				for k, v := range jwtDetails(header, claims, e.scanTime()) {
+					f.Validation.Details[k] = v
+				}
+				if reason != "" {
+					f.Validation.Details["severity_reason"] = reason
+				}
+				findings = append(findings, f)
+			}
+		}
+	}
+	return findings
+}
+
+// jwtSeverity picks the rule's configured severity for the token's state.
+// Downgrades for unsigned and expired tokens take precedence over upgrades.
+func jwtSeverity(rule rules.Rule, header map[string]any, claims map[string]any, now time.Time) (string, string) {
+	spec := rule.Detection.JWT
+	pick := func(sev string, reason string) (string, string) {
+		if sev == "" {
+			return rule.Severity, ""
+		}
+		return sev, reason
+	}
+
+	alg, _ := header["alg"].(string)
+	exp, hasExp := offline.NumericDate(claims["exp"])
+	iat, hasIat := offline.NumericDate(claims["iat"])
+	switch {
+	case strings.EqualFold(alg, "none"):
+		return pick(spec.AlgNoneSeverity, "alg-none")
+	case hasExp && exp.Before(now):
+		return pick(spec.ExpiredSeverity, "expired")
+	case !hasExp:
+		return pick(spec.NoExpirySeverity, "no-expiry")
+	case rule.JWTLongLivedAfter > 0 && hasIat && exp.Sub(iat) > rule.JWTLongLivedAfter:
+		return pick(spec.LongLivedSeverity, "long-lived")
+	}
+	return rule.Severity, ""
+}
+
+// jwtDetails lists the header and claim values worth triaging on.
+func jwtDetails(header map[string]any, claims map[string]any, now time.Time) map[string]any {
+	details := map[string]any{}
+	if alg, ok := header["alg"].(string); ok {
+		details["alg"] = alg
+	}
+	for _, k := range []string{"iss", "aud", "sub"} {
+		if v, ok := claims[k]; ok {
+			details[k] = v
+		}
+	}
+	if exp, ok := offline.NumericDate(claims["exp"]); ok {
+		details["exp"] = exp.UTC().Format(time.RFC3339)
+		details["expired"] = exp.Before(now)
+	}
+	if iat, ok := offline.NumericDate(claims["iat"]); ok {
+		details["iat"] = iat.UTC().Format(time.RFC3339)
+	}
+	return details
+}
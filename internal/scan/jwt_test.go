@@ -0,0 +1,116 @@
+package scan
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRunJWTSeverityFollowsClaims(t *testing.T) {
+	now := time.Date(2026, 6, 1, 0, 0, 0, 0, time.UTC)
+	iat := now.Add(-time.Hour).Unix()
+	token := func(header string, claims string) string {
+		enc := base64.RawURLEncoding.EncodeToString
+		return enc([]byte(header)) + "." + enc([]byte(claims)) + ".c2lnbmF0dXJlLWJ5dGVz"
+	}
+	hs := `{"alg":"HS256","typ":"JWT"}`
+	tokens := map[string]string{
+		"short":     token(hs, fmt.Sprintf(`{"iss":"auth.example.com","aud":"api","sub":"user-1","iat":%d,"exp":%d}`, iat, now.Add(time.Hour).Unix())),
+		"expired":   token(hs, fmt.Sprintf(`{"sub":"user-2","iat":%d,"exp":%d}`, iat, now.Add(-time.Minute).Unix())),
+		"unsigned":  token(`{"alg":"none"}`, fmt.Sprintf(`{"sub":"user-3","exp":%d}`, now.Add(time.Hour).Unix())),
+		"forever":   token(hs, `{"sub":"service-account"}`),
+		"long-life": token(hs, fmt.Sprintf(`{"sub":"user-4","iat":%d,"exp":%d}`, iat, now.AddDate(1, 0, 0).Unix())),
+	}
+	var b strings.Builder
+	for name, tok := range tokens {
+		fmt.Fprintf(&b, "%s: Bearer %s\n", name, tok)
+	}
+	tmp := t.TempDir()
+	if err := os.WriteFile(filepath.Join(tmp, "session.har"), []byte(b.String()), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	res, err := Run(context.Background(), Options{
+		Target:             tmp,
+		PolicyPath:         filepath.Join(tmp, "policy.yaml"),
+		BaselinePath:       filepath.Join(tmp, "baseline.json"),
+		Severity:           "low",
+		MaxTargetMegabytes: 5,
+		Version:            "test",
+		Now:                now,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := map[string]string{
+		tokens["short"]:     "medium",
+		tokens["expired"]:   "low",
+		tokens["unsigned"]:  "low",
+		tokens["forever"]:   "high",
+		tokens["long-life"]: "high",
+	}
+	for _, f := range res.Report.Findings {
+		if f.RuleID != "jwt" {
+			t.Fatalf("expected only jwt findings, got %s on %q", f.RuleID, f.RawSecret)
+		}
+		if want[f.RawSecret] != f.Severity {
+			t.Fatalf("expected severity %q for %v, got %q", want[f.RawSecret], f.Validation.Details, f.Severity)
+		}
+		if f.RawSecret == tokens["short"] {
+			d := f.Validation.Details
+			if d["iss"] != "auth.example.com" || d["aud"] != "api" || d["sub"] != "user-1" || d["alg"] != "HS256" || d["expired"] != false {
+				t.Fatalf("unexpected claims: %+v", d)
+			}
+		}
+		delete(want, f.RawSecret)
+	}
+	if len(want) != 0 {
+		t.Fatalf("missing jwt findings: %v", want)
+	}
+}
+
+func TestRunDoesNotCacheJWTSeverityAcrossExpiry(t *testing.T) {
+	now := time.Date(2026, 6, 1, 0, 0, 0, 0, time.UTC)
+	enc := base64.RawURLEncoding.EncodeToString
+	tok := enc([]byte(`{"alg":"HS256","typ":"JWT"}`)) + "." +
+		enc([]byte(fmt.Sprintf(`{"sub":"user-1","iat":%d,"exp":%d}`, now.Add(-time.Hour).Unix(), now.Add(time.Hour).Unix()))) +
+		".c2lnbmF0dXJlLWJ5dGVz"
+	tmp := t.TempDir()
+	if err := os.WriteFile(filepath.Join(tmp, "session.har"), []byte("auth: Bearer "+tok+"\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	run := func(at time.Time) Result {
+		res, err := Run(context.Background(), Options{
+			Target:             tmp,
+			PolicyPath:         filepath.Join(tmp, "policy.yaml"),
+			BaselinePath:       filepath.Join(tmp, "baseline.json"),
+			Severity:           "low",
+			MaxTargetMegabytes: 5,
+			Version:            "test",
+			Now:                at,
+			CacheDir:           filepath.Join(tmp, ".secrethawk", "cache"),
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(res.Report.Findings) != 1 {
+			t.Fatalf("expected 1 finding, got %+v", res.Report.Findings)
+		}
+		return res
+	}
+
+	if f := run(now).Report.Findings[0]; f.Severity != "medium" {
+		t.Fatalf("expected medium before expiry, got %s", f.Severity)
+	}
+	later := run(now.Add(2 * time.Hour))
+	if f := later.Report.Findings[0]; f.Severity != "low" || f.Validation.Details["expired"] != true {
+		t.Fatalf("expected expired token after exp, got %s %+v", f.Severity, f.Validation.Details)
+	}
+}
@@ -0,0 +1,60 @@
+package scan
+
+import (
+	"strings"
+
+	"github.com/peter941221/secrethawk/internal/model"
+	"github.com/peter941221/secrethawk/internal/rules"
+	"github.com/peter941221/secrethawk/internal/severity"
+	"github.com/peter941221/secrethawk/internal/structured"
+)
+
+// scanKeyValues parses recognised config formats into key paths and runs the
+// keyvalue rules over each value. Files that fail to parse are left to the
+// line-based rules.
+func (e *engine) scanKeyValues(path string, text string) []model.Finding {
+	kvRules := make([]rules.Rule, 0)
+	for _, r := range e.rules {
+		if r.Type == rules.TypeKeyValue && severity.MeetsOrAbove(r.Severity, e.threshold) {
+			kvRules = append(kvRules, r)
+		}
+	}
+	if len(kvRules) == 0 || structured.Format(path) == "" {
+		return nil
+	}
+	entries, err := structured.Parse(path, []byte(text))
+	if err != nil {
+		return nil
+	}
+
+	lines := strings.Split(strings.ReplaceAll(text, "\r\n", "\n"), "\n")
+	findings := make([]model.Finding, 0)
+	for _, entry := range entries {
+		line := ""
+		if entry.Line >= 1 && entry.Line <= len(lines) {
+			line = lines[entry.Line-1]
+		}
+		for _, r := range kvRules {
+			if !rules.MatchKeyValue(r, entry.Path, entry.Value) {
+				continue
+			}
+			if isAllowlisted(e.policy, path, r.ID, entry.Value, line, nil) {
+				continue
+			}
+			f := makeFinding(path, entry.Line, entry.Value, line, r.ID, r.Name, r.Severity, r.Category, nil)
+			f.Validation.Details["key_path"] = entry.Path
+			findings = append(findings, f)
+			break
+		}
+	}
+	return findings
+}
+
+// secretsByLine indexes the secrets already reported on each line.
+func secretsByLine(findings []model.Finding) map[int][]string {
+	out := map[int][]string{}
+	for _, f := range findings {
+		out[f.Location.LineStart] = append(out[f.Location.LineStart], f.RawSecret)
+	}
+	return out
+}
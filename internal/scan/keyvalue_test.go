@@ -0,0 +1,56 @@
+package scan
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestRunReportsConfigValuesWithKeyPaths(t *testing.T) {
+	tmp := t.TempDir()
+	files := map[string]string{
+		"application.properties": "spring.datasource.url=jdbc:postgresql://db/app\nspring.datasource.password=Pr0d-Db-Pa55\n",
+		"config.json":            "{\n  \"service\": {\"db_password\": \"json-secret-42\"}\n}\n",
+		"values.yaml":            "auth:\n  password: ${DB_PASSWORD}\n  token: changeme\n",
+		".env":                   "API_TOKEN=xxxxxxxxxxxx\nUSER_NAME=administrator\n",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(tmp, name), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	res, err := Run(context.Background(), Options{
+		Target:             tmp,
+		PolicyPath:         filepath.Join(tmp, "policy.yaml"),
+		BaselinePath:       filepath.Join(tmp, "baseline.json"),
+		Severity:           "low",
+		MaxTargetMegabytes: 5,
+		Version:            "test",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := map[string]string{
+		"application.properties": "spring.datasource.password",
+		"config.json":            "service.db_password",
+	}
+	for _, f := range res.Report.Findings {
+		if f.RuleID != "config-secret-value" {
+			continue
+		}
+		name := filepath.Base(f.Location.File)
+		if want[name] == "" || f.Validation.Details["key_path"] != want[name] {
+			t.Fatalf("unexpected config finding in %s: %+v", name, f.Validation.Details)
+		}
+		if f.Location.LineStart != 2 {
+			t.Fatalf("expected line 2 in %s, got %d", name, f.Location.LineStart)
+		}
+		delete(want, name)
+	}
+	if len(want) != 0 {
+		t.Fatalf("missing config findings: %v", want)
+	}
+}
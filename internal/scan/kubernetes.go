@@ -0,0 +1,225 @@
+package scan
+
+import (
+	"encoding/base64"
+	"path/filepath"
+	"strings"
+
+	"github.com/peter941221/secrethawk/internal/model"
+	"github.com/peter941221/secrethawk/internal/rules"
+	"github.com/peter941221/secrethawk/internal/severity"
+	"github.com/peter941221/secrethawk/internal/structured"
+)
+
+// secretValue is one data or stringData entry of a Secret manifest or Helm
+// values file.
+type secretValue struct {
+	// Address locates the entry in the file, e.g. Secret/prod/db.data.PASSWORD
+	// for manifests or the key path for Helm values.
+	Address string
+	Field   string
+	Key     string
+	Raw     string
+	Line    int
+	Details map[string]any
+}
+
+// scanKubernetes reports the entries of Kubernetes Secret manifests, in any
+// document of a multi-document YAML file, and of the Secret data and
+// stringData maps in Helm values files. data entries are base64-decoded
+// before matching. Each plaintext value is matched against the rules as
+// "KEY: value" and, when nothing matches, reported under the kubernetes rule.
+// The returned map lists the raw values per line so plain line matches on
+// them can be dropped.
+func (e *engine) scanKubernetes(path string, text string) ([]model.Finding, map[int][]string) {
+	if structured.Format(path) != "yaml" {
+		return nil, nil
+	}
+	helm := isHelmValues(path)
+	if !helm && !strings.Contains(text, "Secret") {
+		return nil, nil
+	}
+	docs, err := structured.ParseYAMLDocuments([]byte(text))
+	if err != nil {
+		return nil, nil
+	}
+
+	lines := strings.Split(strings.ReplaceAll(text, "\r\n", "\n"), "\n")
+	findings := make([]model.Finding, 0)
+	covered := map[int][]string{}
+	for _, doc := range docs {
+		// Sealed Secrets hold ciphertext that is meant to be committed.
+		for _, entry := range sealedSecretValues(doc) {
+			covered[entry.Line] = append(covered[entry.Line], entry.Value)
+		}
+		values := manifestSecretValues(doc)
+		if values == nil && helm {
+			values = helmSecretValues(doc)
+		}
+		for _, v := range values {
+			line := ""
+			if v.Line >= 1 && v.Line <= len(lines) {
+				line = lines[v.Line-1]
+			}
+			plain, encoding := v.Raw, ""
+			if v.Field == "data" {
+				decoded, err := base64.StdEncoding.DecodeString(strings.Join(strings.Fields(v.Raw), ""))
+				if err == nil && len(decoded) > 0 && isText(string(decoded)) {
+					plain, encoding = string(decoded), "base64"
+				}
+			}
+			if rules.IsPlaceholder(plain) {
+				continue
+			}
+			// Block scalars start on the line after their key.
+			span := 0
+			if strings.Contains(v.Raw, "\n") {
+				span = strings.Count(strings.TrimRight(v.Raw, "\n"), "\n") + 1
+			}
+			for i := 0; i <= span; i++ {
+				covered[v.Line+i] = append(covered[v.Line+i], v.Raw, plain)
+			}
+
+			fnds := e.scanValue(path, v.Key, plain)
+			if len(fnds) == 0 && e.kubernetesRule != nil {
+				r := e.kubernetesRule
+				if severity.MeetsOrAbove(r.Severity, e.threshold) && !isAllowlisted(e.policy, path, r.ID, plain, line, nil) {
+					fnds = append(fnds, makeFinding(path, 1, plain, plain, r.ID, r.Name, r.Severity, r.Category, nil))
+				}
+			}
+			for _, f := range fnds {
+				relocate(&f, path, v.Address, v.Line, line)
+				if encoding != "" && f.Match.Encoding == "" {
+					f.Match.Encoding = encoding
+				}
+				for k, val := range v.Details {
+					f.Validation.Details[k] = val
+				}
+				findings = append(findings, f)
+			}
+		}
+	}
+	return findings, covered
+}
+
+// manifestSecretValues returns the data and stringData entries of a v1
+// Secret, or nil when the document is not one.
+func manifestSecretValues(doc []structured.Entry) []secretValue {
+	fields := map[string]string{}
+	for _, entry := range doc {
+		fields[entry.Path] = entry.Value
+	}
+	if fields["kind"] != "Secret" || fields["apiVersion"] != "v1" {
+		return nil
+	}
+	object := "Secret/" + fields["metadata.name"]
+	if ns := fields["metadata.namespace"]; ns != "" {
+		object = "Secret/" + ns + "/" + fields["metadata.name"]
+	}
+	values := make([]secretValue, 0)
+	for _, entry := range doc {
+		for _, field := range []string{"data", "stringData"} {
+			key, ok := strings.CutPrefix(entry.Path, field+".")
+			if !ok {
+				continue
+			}
+			values = append(values, secretValue{
+				Address: object + "." + entry.Path,
+				Field:   field,
+				Key:     key,
+				Raw:     entry.Value,
+				Line:    entry.Line,
+				Details: map[string]any{
+					"kind":      "Secret",
+					"namespace": fields["metadata.namespace"],
+					"name":      fields["metadata.name"],
+					"field":     field,
+					"key":       key,
+				},
+			})
+		}
+	}
+	return values
+}
+
+// sealedSecretValues returns the encryptedData entries of a SealedSecret.
+func sealedSecretValues(doc []structured.Entry) []structured.Entry {
+	sealed := false
+	for _, entry := range doc {
+		if entry.Path == "kind" && entry.Value == "SealedSecret" {
+			sealed = true
+		}
+	}
+	if !sealed {
+		return nil
+	}
+	entries := make([]structured.Entry, 0)
+	for _, entry := range doc {
+		if strings.HasPrefix(entry.Path, "spec.encryptedData.") {
+			entries = append(entries, entry)
+		}
+	}
+	return entries
+}
+
+// helmSecretValues returns the entries of the data and stringData maps in a
+// Helm values document that charts render into Secrets: maps under a secret
+// or secrets key, or next to kind: Secret or type: Opaque. Other data maps,
+// such as ConfigMap values, are left to the line scanner.
+func helmSecretValues(doc []structured.Entry) []secretValue {
+	fields := map[string]string{}
+	for _, entry := range doc {
+		fields[entry.Path] = entry.Value
+	}
+	values := make([]secretValue, 0)
+	for _, entry := range doc {
+		for _, field := range []string{"data", "stringData"} {
+			var parent, key string
+			if k, ok := strings.CutPrefix(entry.Path, field+"."); ok {
+				key = k
+			} else if i := strings.LastIndex(entry.Path, "."+field+"."); i >= 0 {
+				parent, key = entry.Path[:i], entry.Path[i+len(field)+2:]
+			} else {
+				continue
+			}
+			if strings.ContainsAny(key, "[") || !isHelmSecret(parent, fields) {
+				continue
+			}
+			values = append(values, secretValue{
+				Address: entry.Path,
+				Field:   field,
+				Key:     key,
+				Raw:     entry.Value,
+				Line:    entry.Line,
+				Details: map[string]any{"field": field, "key": key, "key_path": entry.Path},
+			})
+		}
+	}
+	return values
+}
+
+// isHelmSecret reports whether the values object at parent describes a
+// Secret, either by a secret/secrets key on its path or by its own kind or
+// type.
+func isHelmSecret(parent string, fields map[string]string) bool {
+	for _, name := range strings.Split(parent, ".") {
+		if i := strings.IndexByte(name, '['); i >= 0 {
+			name = name[:i]
+		}
+		if n := strings.ToLower(name); n == "secret" || n == "secrets" {
+			return true
+		}
+	}
+	prefix := ""
+	if parent != "" {
+		prefix = parent + "."
+	}
+	return fields[prefix+"kind"] == "Secret" || fields[prefix+"type"] == "Opaque"
+}
+
+// isHelmValues matches values.yaml and its per-environment variants such as
+// values-prod.yaml.
+func isHelmValues(path string) bool {
+	base := strings.ToLower(filepath.Base(path))
+	return strings.HasPrefix(base, "values") && structured.Format(base) == "yaml"
+}
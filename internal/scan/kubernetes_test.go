@@ -0,0 +1,92 @@
+package scan
+
+import (
+	"context"
+	"encoding/base64"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRunKubernetesSecretDecodesData(t *testing.T) {
+	tmp := t.TempDir()
+	b64 := base64.StdEncoding.EncodeToString
+	manifest := "apiVersion: v1\n" +
+		"kind: ConfigMap\n" +
+		"metadata:\n" +
+		"  name: app\n" +
+		"data:\n" +
+		"  LOG_LEVEL: debug\n" +
+		"---\n" +
+		"apiVersion: v1\n" +
+		"kind: Secret\n" +
+		"metadata:\n" +
+		"  name: db\n" +
+		"  namespace: prod\n" +
+		"type: Opaque\n" +
+		"data:\n" +
+		"  DB_PASSWORD: " + b64([]byte("Qp7!vLm2#Xw9")) + "\n" +
+		"  AWS_ACCESS_KEY_ID: " + b64([]byte(testAWSKey())) + "\n" +
+		"stringData:\n" +
+		"  SESSION_SECRET: s3ss10n-v4lue-9x\n" +
+		"  API_KEY: ${API_KEY}\n" +
+		"---\n" +
+		"apiVersion: bitnami.com/v1alpha1\n" +
+		"kind: SealedSecret\n" +
+		"metadata:\n" +
+		"  name: sealed\n" +
+		"spec:\n" +
+		"  encryptedData:\n" +
+		"    TOKEN: AgBy3i4OJSWK+PiTySYZZA==\n"
+	if err := os.MkdirAll(filepath.Join(tmp, "manifests"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(tmp, "manifests", "app.yaml"), []byte(manifest), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	values := "app:\n  secret:\n    data:\n      TOKEN: " + b64([]byte("tkn-8fJq2LwX0pZr")) + "\n" +
+		"configMap:\n  data:\n    LOG_LEVEL: info\n    REGION: eu-west-1\n    FEATURE_FLAGS: search,checkout\n" +
+		"extraObjects:\n  - kind: Secret\n    stringData:\n      API_PASS: pw-9Hq2Lx7Rt4Kz\n"
+	if err := os.WriteFile(filepath.Join(tmp, "values-prod.yaml"), []byte(values), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	res, err := Run(context.Background(), Options{
+		Target:             tmp,
+		PolicyPath:         filepath.Join(tmp, "policy.yaml"),
+		BaselinePath:       filepath.Join(tmp, "baseline.json"),
+		Severity:           "low",
+		MaxTargetMegabytes: 5,
+		Version:            "test",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	type want struct {
+		rule     string
+		line     int
+		secret   string
+		encoding string
+	}
+	wants := map[string]want{
+		"manifests/app.yaml#Secret/prod/db.data.DB_PASSWORD":          {"kubernetes-secret-value", 15, "Qp7!vLm2#Xw9", "base64"},
+		"manifests/app.yaml#Secret/prod/db.data.AWS_ACCESS_KEY_ID":    {"aws-access-key-id", 16, testAWSKey(), "base64"},
+		"manifests/app.yaml#Secret/prod/db.stringData.SESSION_SECRET": {"kubernetes-secret-value", 18, "s3ss10n-v4lue-9x", ""},
+		"values-prod.yaml#app.secret.data.TOKEN":                      {"kubernetes-secret-value", 4, "tkn-8fJq2LwX0pZr", "base64"},
+		"values-prod.yaml#extraObjects[0].stringData.API_PASS":        {"kubernetes-secret-value", 13, "pw-9Hq2Lx7Rt4Kz", ""},
+	}
+	if len(res.Report.Findings) != len(wants) {
+		t.Fatalf("expected %d findings, got %+v", len(wants), res.Report.Findings)
+	}
+	for _, f := range res.Report.Findings {
+		w, ok := wants[strings.TrimPrefix(filepath.ToSlash(f.Location.File), filepath.ToSlash(tmp)+"/")+"#"+f.Location.Address]
+		if !ok {
+			t.Fatalf("unexpected finding at %s: %+v", f.Location.File, f)
+		}
+		if f.RuleID != w.rule || f.Location.LineStart != w.line || f.RawSecret != w.secret || f.Match.Encoding != w.encoding {
+			t.Fatalf("unexpected finding at %s: %+v", f.Location.File, f)
+		}
+	}
+}
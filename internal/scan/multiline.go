@@ -0,0 +1,120 @@
+package scan
+
+import (
+	"strings"
+
+	"github.com/peter941221/secrethawk/internal/baseline"
+	"github.com/peter941221/secrethawk/internal/model"
+	"github.com/peter941221/secrethawk/internal/rules"
+	"github.com/peter941221/secrethawk/internal/severity"
+)
+
+// scanMultiline evaluates rules marked detection.multiline against the whole
+// text so a single match (e.g. a PEM block) can span several lines.
+func (e *engine) scanMultiline(path string, text string) []model.Finding {
+	findings := make([]model.Finding, 0)
+	var lineStarts []int
+	var textLines []string
+	var candidates []bool
+	for i, rule := range e.rules {
+		if rule.Type != rules.TypeRegex || !rule.Detection.Multiline {
+			continue
+		}
+		if candidates == nil {
+			candidates = e.keywords.Candidates(text)
+		}
+		if !candidates[i] {
+			continue
+		}
+		if !severity.MeetsOrAbove(rule.Severity, e.threshold) {
+			continue
+		}
+		if lineStarts == nil {
+			lineStarts = lineOffsets(text)
+			textLines = strings.Split(text, "\n")
+		}
+		for _, idx := range rule.Regex.FindAllStringSubmatchIndex(text, -1) {
+			start, end := idx[0], idx[1]
+			if len(idx) >= 4 && idx[2] >= 0 && idx[3] >= 0 {
+				start, end = idx[2], idx[3]
+			}
+			secret := text[start:end]
+			if strings.TrimSpace(secret) == "" {
+				continue
+			}
+			startLine, startCol := position(lineStarts, start)
+			endLine, endCol := position(lineStarts, end-1)
+			firstLine := lineAt(text, lineStarts, startLine)
+			if !rules.MatchContextWindow(rule, textLines, startLine-1, endLine-1) {
+				continue
+			}
+			if isAllowlisted(e.policy, path, rule.ID, secret, firstLine, nil) {
+				continue
+			}
+			f := makeFinding(path, startLine, secret, secret, rule.ID, rule.Name, rule.Severity, rule.Category, nil)
+			f.Location.LineEnd = endLine
+			f.Location.ColumnStart = startCol
+			f.Location.ColumnEnd = endCol
+			f.LineHash = baseline.ComputeLineHash(secret)
+			findings = append(findings, f)
+		}
+	}
+	return findings
+}
+
+// dropCoveredLines removes findings whose line falls inside the span of a
+// multi-line finding, so key material is not reported again as entropy noise.
+func dropCoveredLines(findings []model.Finding, spans []model.Finding) []model.Finding {
+	if len(spans) == 0 {
+		return findings
+	}
+	out := make([]model.Finding, 0, len(findings))
+	for _, f := range findings {
+		covered := false
+		for _, s := range spans {
+			if f.Location.LineStart >= s.Location.LineStart && f.Location.LineStart <= s.Location.LineEnd {
+				covered = true
+				break
+			}
+		}
+		if !covered {
+			out = append(out, f)
+		}
+	}
+	return out
+}
+
+func lineOffsets(text string) []int {
+	offsets := []int{0}
+	for i := 0; i < len(text); i++ {
+		if text[i] == '\n' {
+			offsets = append(offsets, i+1)
+		}
+	}
+	return offsets
+}
+
+// position converts a byte offset into a 1-based line and column.
+func position(lineStarts []int, offset int) (int, int) {
+	lo, hi := 0, len(lineStarts)-1
+	for lo < hi {
+		mid := (lo + hi + 1) / 2
+		if lineStarts[mid] <= offset {
+			lo = mid
+		} else {
+			hi = mid - 1
+		}
+	}
+	return lo + 1, offset - lineStarts[lo] + 1
+}
+
+func lineEndOffset(text string, lineStarts []int, lineNo int) int {
+	if lineNo < len(lineStarts) {
+		return lineStarts[lineNo] - 1
+	}
+	return len(text)
+}
+
+func lineAt(text string, lineStarts []int, lineNo int) string {
+	return strings.TrimSuffix(text[lineStarts[lineNo-1]:lineEndOffset(text, lineStarts, lineNo)], "\r")
+}
@@ -0,0 +1,123 @@
+package scan
+
+import (
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/peter941221/secrethawk/internal/model"
+)
+
+var (
+	// notebookSourceRE matches the address of a notebook cell's source.
+	notebookSourceRE = regexp.MustCompile(`^cell (\d+) source$`)
+	ansiEscapeRE     = regexp.MustCompile("\x1b\\[[0-9;]*[A-Za-z]")
+)
+
+// notebookText is a multi-line notebook string, stored either as one string
+// or as a list of lines that keep their trailing newlines.
+type notebookText string
+
+func (t *notebookText) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err == nil {
+		*t = notebookText(s)
+		return nil
+	}
+	var lines []string
+	if err := json.Unmarshal(data, &lines); err != nil {
+		return err
+	}
+	*t = notebookText(strings.Join(lines, ""))
+	return nil
+}
+
+// notebook covers the parts of an nbformat 4 document that hold text.
+type notebook struct {
+	NBFormat int `json:"nbformat"`
+	Cells    []struct {
+		Source  notebookText `json:"source"`
+		Outputs []struct {
+			OutputType string                     `json:"output_type"`
+			Text       notebookText               `json:"text"`
+			Data       map[string]json.RawMessage `json:"data"`
+			EName      string                     `json:"ename"`
+			EValue     string                     `json:"evalue"`
+			Traceback  []string                   `json:"traceback"`
+		} `json:"outputs"`
+	} `json:"cells"`
+}
+
+// NotebookSourceCell reports whether a finding location points into the
+// source of a Jupyter notebook cell, and returns the 0-based index of the
+// cell in the notebook's cells array.
+func NotebookSourceCell(loc model.Location) (int, bool) {
+	m := notebookSourceRE.FindStringSubmatch(loc.Address)
+	if m == nil || !isNotebook(loc.File) {
+		return 0, false
+	}
+	cell, err := strconv.Atoi(m[1])
+	if err != nil || cell < 1 {
+		return 0, false
+	}
+	return cell - 1, true
+}
+
+func isNotebook(path string) bool {
+	return strings.EqualFold(filepath.Ext(path), ".ipynb")
+}
+
+// scanNotebook scans the source and every text output of each cell of a
+// Jupyter notebook separately. Findings are located at "cell N source" or
+// "cell N output M", numbered from 1 as Jupyter shows them, with line numbers
+// counted inside that text. It returns false when data is not an nbformat 4 notebook, so the
+// caller can fall back to the line scanner.
+func (e *engine) scanNotebook(path string, data []byte) ([]model.Finding, bool) {
+	var nb notebook
+	if err := json.Unmarshal(data, &nb); err != nil || nb.NBFormat < 4 {
+		return nil, false
+	}
+	findings := make([]model.Finding, 0)
+	for i, cell := range nb.Cells {
+		texts := []struct{ address, text string }{
+			{fmt.Sprintf("cell %d source", i+1), string(cell.Source)},
+		}
+		for j, out := range cell.Outputs {
+			var text string
+			switch out.OutputType {
+			case "stream":
+				text = string(out.Text)
+			case "error":
+				text = out.EName + ": " + out.EValue + "\n" + strings.Join(out.Traceback, "\n")
+			default:
+				// Rich outputs also carry images and HTML; text/plain is
+				// the rendering that holds printed values.
+				var plain notebookText
+				if raw, ok := out.Data["text/plain"]; ok && json.Unmarshal(raw, &plain) == nil {
+					text = string(plain)
+				}
+			}
+			texts = append(texts, struct{ address, text string }{
+				fmt.Sprintf("cell %d output %d", i+1, j+1), ansiEscapeRE.ReplaceAllString(text, ""),
+			})
+		}
+		for _, t := range texts {
+			if strings.TrimSpace(t.text) == "" {
+				continue
+			}
+			fnds, err := e.scanContent(path, t.text)
+			if err != nil {
+				return nil, false
+			}
+			for _, f := range fnds {
+				f.Location.Address = t.address
+				f.ID = findingID(f.RuleID, locationKey(f.Location), f.Location.LineStart, f.LineHash, nil)
+				findings = append(findings, f)
+			}
+		}
+	}
+	return findings, true
+}
@@ -0,0 +1,67 @@
+package scan
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRunNotebookLocatesCellSourceAndOutputs(t *testing.T) {
+	tmp := t.TempDir()
+	key := func(suffix string) string {
+		return strings.TrimSuffix(testAWSKey(), "F7") + suffix
+	}
+	nb := fmt.Sprintf(`{
+ "cells": [
+  {"cell_type": "markdown", "metadata": {}, "source": ["# Load data\n"]},
+  {
+   "cell_type": "code",
+   "execution_count": 1,
+   "metadata": {},
+   "outputs": [
+    {"name": "stdout", "output_type": "stream", "text": ["connecting\n", "AWS_ACCESS_KEY_ID: %s\n"]},
+    {"data": {"image/png": "iVBORw0KGgo=", "text/plain": ["<Figure>"]}, "metadata": {}, "output_type": "display_data"},
+    {"ename": "ClientError", "evalue": "denied", "output_type": "error", "traceback": ["\u001b[0;31mClientError\u001b[0m", "key %s rejected"]}
+   ],
+   "source": ["import os\n", "aws_key = \"%s\"\n", "print(os.environ)"]
+  }
+ ],
+ "metadata": {"kernelspec": {"language": "python", "name": "python3"}},
+ "nbformat": 4,
+ "nbformat_minor": 5
+}
+`, key("F2"), key("F3"), key("F4"))
+	if err := os.WriteFile(filepath.Join(tmp, "analysis.ipynb"), []byte(nb), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	res, err := Run(context.Background(), Options{
+		Target:             tmp,
+		PolicyPath:         filepath.Join(tmp, "policy.yaml"),
+		BaselinePath:       filepath.Join(tmp, "baseline.json"),
+		Severity:           "high",
+		MaxTargetMegabytes: 5,
+		Version:            "test",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := map[string]string{
+		key("F4"): "analysis.ipynb#cell 2 source:2",
+		key("F2"): "analysis.ipynb#cell 2 output 1:2",
+		key("F3"): "analysis.ipynb#cell 2 output 3:3",
+	}
+	if len(res.Report.Findings) != len(want) {
+		t.Fatalf("expected %d findings, got %+v", len(want), res.Report.Findings)
+	}
+	for _, f := range res.Report.Findings {
+		got := fmt.Sprintf("%s#%s:%d", filepath.Base(f.Location.File), f.Location.Address, f.Location.LineStart)
+		if want[f.RawSecret] != got {
+			t.Fatalf("expected %s at %s, got %s", f.RawSecret, want[f.RawSecret], got)
+		}
+	}
+}
@@ -0,0 +1,33 @@
+package scan
+
+import (
+	"time"
+
+	"github.com/peter941221/secrethawk/internal/model"
+	"github.com/peter941221/secrethawk/internal/offline"
+)
+
+// checkOffline records structure and checksum results for token formats that
+// can be verified locally. It runs as the first step of validation. Tokens
+// failing their check are marked invalid-format with low confidence; no
+// connector is asked about them. now is the scan time used for expiry.
+func checkOffline(f *model.Finding, now time.Time) {
+	res, ok := offline.Check(f.RawSecret, now)
+	if !ok {
+		return
+	}
+	if f.Validation.Details == nil {
+		f.Validation.Details = map[string]any{}
+	}
+	// Details a rule already recorded, e.g. JWT claims, take precedence.
+	for k, v := range res.Details {
+		if _, set := f.Validation.Details[k]; !set {
+			f.Validation.Details[k] = v
+		}
+	}
+	f.Validation.Method = "offline"
+	if !res.Valid {
+		f.Validation.Status = offline.StatusInvalidFormat
+		f.Confidence = confidenceFromValidation(f.Confidence, f.Validation.Status)
+	}
+}
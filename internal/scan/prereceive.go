@@ -0,0 +1,79 @@
+package scan
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/peter941221/secrethawk/internal/model"
+)
+
+// RefUpdate is one "<old> <new> <ref>" line a pre-receive hook reads on stdin.
+type RefUpdate struct {
+	Old string
+	New string
+	Ref string
+}
+
+// ParseRefUpdates reads pre-receive hook input.
+func ParseRefUpdates(r io.Reader) ([]RefUpdate, error) {
+	updates := make([]RefUpdate, 0)
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		fields := strings.Fields(line)
+		if len(fields) != 3 {
+			return nil, fmt.Errorf("invalid ref update line: %q", line)
+		}
+		updates = append(updates, RefUpdate{Old: fields[0], New: fields[1], Ref: fields[2]})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return updates, nil
+}
+
+// isZeroOID reports whether oid is git's all-zero id for a missing ref side.
+func isZeroOID(oid string) bool {
+	return oid != "" && strings.Trim(oid, "0") == ""
+}
+
+// scanRefUpdates scans the commits a push introduces: those reachable from
+// each new tip but not from any existing ref. It reads blobs through git
+// diff-tree, so it works in bare repositories and the push quarantine. Branch
+// deletions are skipped.
+func (e *engine) scanRefUpdates(ctx context.Context, repo string, threads int, updates []RefUpdate) ([]model.Finding, int, error) {
+	commits := make([]historyCommit, 0)
+	refsByCommit := map[string][]string{}
+	for _, u := range updates {
+		if isZeroOID(u.New) {
+			continue
+		}
+		cs, err := listHistoryCommits(ctx, repo, u.New, "--not", "--all")
+		if err != nil {
+			return nil, 0, err
+		}
+		for _, c := range cs {
+			if _, seen := refsByCommit[c.SHA]; !seen {
+				commits = append(commits, c)
+			}
+			refsByCommit[c.SHA] = append(refsByCommit[c.SHA], strings.TrimPrefix(u.Ref, "refs/heads/"))
+		}
+	}
+
+	findings, filesScanned, err := e.scanCommits(ctx, repo, threads, commits)
+	if err != nil {
+		return nil, 0, err
+	}
+	for i := range findings {
+		if c := findings[i].Location.Commit; c != nil {
+			findings[i].Location.Branch = strings.Join(refsByCommit[*c], ",")
+		}
+	}
+	return findings, filesScanned, nil
+}
@@ -0,0 +1,110 @@
+package scan
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/peter941221/secrethawk/internal/model"
+)
+
+var (
+	inlineDirectiveRE = regexp.MustCompile(`secrethawk:allow\s+([A-Za-z0-9_.*,-]+)(?:\s+reason=(?:"([^"]*)"|'([^']*)'))?`)
+	commentMarkers    = []string{"#", "//", "--", "/*", "<!--"}
+)
+
+type inlineDirective struct {
+	rules  []string
+	reason string
+	line   int
+}
+
+func (d inlineDirective) covers(ruleID string) bool {
+	for _, r := range d.rules {
+		if r == "*" || r == ruleID {
+			return true
+		}
+	}
+	return false
+}
+
+// parseInlineDirectives maps each line number to the directives that apply
+// to it. A directive covers its own line and, when it sits on a comment-only
+// line, the line after it. Directives without a reason are ignored.
+func parseInlineDirectives(text string) map[int][]inlineDirective {
+	if !strings.Contains(text, "secrethawk:allow") {
+		return nil
+	}
+	out := map[int][]inlineDirective{}
+	for i, line := range strings.Split(text, "\n") {
+		loc := inlineDirectiveRE.FindStringSubmatchIndex(line)
+		if loc == nil {
+			continue
+		}
+		prefix := line[:loc[0]]
+		if !hasCommentMarker(prefix) {
+			continue
+		}
+		m := inlineDirectiveRE.FindStringSubmatch(line[loc[0]:])
+		reason := strings.TrimSpace(m[2] + m[3])
+		if reason == "" {
+			continue
+		}
+		d := inlineDirective{rules: strings.Split(m[1], ","), reason: reason, line: i + 1}
+		out[i+1] = append(out[i+1], d)
+		if isCommentOnly(prefix) {
+			out[i+2] = append(out[i+2], d)
+		}
+	}
+	return out
+}
+
+// applyInlineSuppressions marks findings covered by an inline directive,
+// unless the policy forbids inline suppression for the finding's severity.
+func (e *engine) applyInlineSuppressions(text string, findings []model.Finding) {
+	if e.policy.Suppression.DisableInline {
+		return
+	}
+	directives := parseInlineDirectives(text)
+	if len(directives) == 0 {
+		return
+	}
+	for i := range findings {
+		if e.inlineForbidden(findings[i].Severity) {
+			continue
+		}
+		for _, d := range directives[findings[i].Location.LineStart] {
+			if d.covers(findings[i].RuleID) {
+				findings[i].Suppression = &model.Suppression{Kind: "inline", Reason: d.reason, Line: d.line}
+				break
+			}
+		}
+	}
+}
+
+func (e *engine) inlineForbidden(sev string) bool {
+	for _, s := range e.policy.Suppression.ForbidInlineSeverities {
+		if strings.EqualFold(s, sev) {
+			return true
+		}
+	}
+	return false
+}
+
+func hasCommentMarker(prefix string) bool {
+	for _, m := range commentMarkers {
+		if strings.Contains(prefix, m) {
+			return true
+		}
+	}
+	return false
+}
+
+func isCommentOnly(prefix string) bool {
+	trimmed := strings.TrimSpace(prefix)
+	for _, m := range commentMarkers {
+		if strings.HasPrefix(trimmed, m) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,63 @@
+package scan
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestRunMarksInlineSuppressedFindings(t *testing.T) {
+	tmp := t.TempDir()
+	key := testAWSKey()
+	source := fmt.Sprintf(`# secrethawk:allow aws-access-key-id reason="test fixture"
+fixture = %q
+other = %q /* secrethawk:allow aws-access-key-id */
+`, key, key)
+	if err := os.WriteFile(filepath.Join(tmp, "fixtures.py"), []byte(source), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	run := func(policy string) Result {
+		policyPath := filepath.Join(tmp, "policy.yaml")
+		if err := os.WriteFile(policyPath, []byte(policy), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		res, err := Run(context.Background(), Options{
+			Target:             filepath.Join(tmp, "fixtures.py"),
+			PolicyPath:         policyPath,
+			BaselinePath:       filepath.Join(tmp, "baseline.json"),
+			Severity:           "critical",
+			FailOn:             "critical",
+			MaxTargetMegabytes: 5,
+			Version:            "test",
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return res
+	}
+
+	res := run("version: \"1\"\n")
+	if len(res.Report.Findings) != 2 {
+		t.Fatalf("expected suppressed finding to stay in report, got %d findings", len(res.Report.Findings))
+	}
+	first, second := res.Report.Findings[0], res.Report.Findings[1]
+	if first.Suppression == nil || first.Suppression.Reason != "test fixture" || first.Suppression.Line != 1 {
+		t.Fatalf("expected line 2 to be suppressed, got %+v", first.Suppression)
+	}
+	if second.Suppression != nil {
+		t.Fatal("directive without reason must not suppress")
+	}
+	if res.Report.Metadata.Suppressed != 1 || res.Report.Metadata.SeverityCounts["critical"] != 1 {
+		t.Fatalf("unexpected counts: suppressed=%d severity=%v", res.Report.Metadata.Suppressed, res.Report.Metadata.SeverityCounts)
+	}
+
+	res = run("version: \"1\"\nsuppression:\n  forbid_inline_severities: [critical]\n")
+	for _, f := range res.Report.Findings {
+		if f.Suppression != nil {
+			t.Fatal("policy should forbid inline suppression of critical findings")
+		}
+	}
+}
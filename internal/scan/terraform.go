@@ -0,0 +1,392 @@
+package scan
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/peter941221/secrethawk/internal/model"
+	"github.com/peter941221/secrethawk/internal/severity"
+)
+
+// tfDocument covers the parts of a tfstate (v4) file and of `terraform show
+// -json` output for plans and states that hold attribute values.
+type tfDocument struct {
+	Version          int                 `json:"version"`
+	TerraformVersion string              `json:"terraform_version"`
+	FormatVersion    string              `json:"format_version"`
+	Outputs          map[string]tfOutput `json:"outputs"`
+	Resources        []tfStateResource   `json:"resources"`
+	Values           *tfValues           `json:"values"`
+	PlannedValues    *tfValues           `json:"planned_values"`
+	PriorState       *struct {
+		Values *tfValues `json:"values"`
+	} `json:"prior_state"`
+	ResourceChanges []struct {
+		Address string `json:"address"`
+		Change  struct {
+			After          any `json:"after"`
+			AfterSensitive any `json:"after_sensitive"`
+		} `json:"change"`
+	} `json:"resource_changes"`
+}
+
+type tfOutput struct {
+	Value     any  `json:"value"`
+	Sensitive bool `json:"sensitive"`
+}
+
+type tfStateResource struct {
+	Module    string `json:"module"`
+	Mode      string `json:"mode"`
+	Type      string `json:"type"`
+	Name      string `json:"name"`
+	Instances []struct {
+		IndexKey            any               `json:"index_key"`
+		Attributes          map[string]any    `json:"attributes"`
+		SensitiveAttributes []json.RawMessage `json:"sensitive_attributes"`
+	} `json:"instances"`
+}
+
+type tfValues struct {
+	Outputs    map[string]tfOutput `json:"outputs"`
+	RootModule tfModule            `json:"root_module"`
+}
+
+type tfModule struct {
+	Resources []struct {
+		Address         string         `json:"address"`
+		Values          map[string]any `json:"values"`
+		SensitiveValues any            `json:"sensitive_values"`
+	} `json:"resources"`
+	ChildModules []tfModule `json:"child_modules"`
+}
+
+// tfLeaf is one string attribute of a resource or output.
+type tfLeaf struct {
+	Address   string
+	Attribute string
+	Value     string
+	Sensitive bool
+}
+
+// isTerraformFile reports whether path is a Terraform state file or the JSON
+// rendering of a plan or state.
+func isTerraformFile(path string, data []byte) bool {
+	base := strings.ToLower(filepath.Base(path))
+	if strings.HasSuffix(base, ".tfstate") || strings.HasSuffix(base, ".tfstate.backup") {
+		return true
+	}
+	if filepath.Ext(base) != ".json" {
+		return false
+	}
+	head := data[:min(len(data), 4096)]
+	return bytes.Contains(head, []byte(`"format_version"`)) && bytes.Contains(head, []byte(`"terraform_version"`))
+}
+
+// scanTerraform walks the resources and outputs of a Terraform state or plan
+// and reports rule matches in attribute values, plus every other non-empty
+// attribute Terraform marks sensitive. Findings are located by resource
+// address and attribute, on the line the value first appears. It returns
+// false when data is not a JSON document Terraform would write, so the
+// caller can fall back to the line scanner.
+func (e *engine) scanTerraform(path string, data []byte) ([]model.Finding, bool) {
+	var doc tfDocument
+	if err := json.Unmarshal(data, &doc); err != nil || doc.TerraformVersion == "" {
+		return nil, false
+	}
+	if doc.FormatVersion == "" && doc.Version != 4 {
+		return nil, false
+	}
+
+	var leaves []tfLeaf
+	for _, r := range doc.Resources {
+		address := r.Type + "." + r.Name
+		if r.Mode == "data" {
+			address = "data." + address
+		}
+		if r.Module != "" {
+			address = r.Module + "." + address
+		}
+		for _, inst := range r.Instances {
+			sensitive := make([]string, 0, len(inst.SensitiveAttributes))
+			for _, raw := range inst.SensitiveAttributes {
+				if p := tfSensitivePath(raw); p != "" {
+					sensitive = append(sensitive, p)
+				}
+			}
+			flattenTF(address+tfIndexKey(inst.IndexKey), "", inst.Attributes, sensitive, &leaves)
+		}
+	}
+	leaves = appendTFOutputs(leaves, doc.Outputs)
+	for _, v := range []*tfValues{doc.Values, doc.PlannedValues, priorStateValues(doc)} {
+		if v == nil {
+			continue
+		}
+		leaves = appendTFOutputs(leaves, v.Outputs)
+		leaves = appendTFModule(leaves, v.RootModule)
+	}
+	for _, rc := range doc.ResourceChanges {
+		flattenTF(rc.Address, "", rc.Change.After, tfMirrorPaths(rc.Change.AfterSensitive, ""), &leaves)
+	}
+
+	text := string(data)
+	lines := strings.Split(strings.ReplaceAll(text, "\r\n", "\n"), "\n")
+	starts := lineOffsets(text)
+	forced := e.policy.Scan.Terraform.Severity
+	findings := make([]model.Finding, 0)
+	seen := map[string]struct{}{}
+	for _, leaf := range leaves {
+		address := leaf.Address
+		if leaf.Attribute != "" {
+			address += "." + leaf.Attribute
+		}
+		// Searching the file for a value is linear, so it is only done
+		// for values that are reported or checked against the allowlist.
+		lineNo, line := 0, ""
+		locate := func() {
+			lineNo = tfValueLine(text, starts, leaf.Value)
+			if lineNo >= 1 && lineNo <= len(lines) {
+				line = lines[lineNo-1]
+			}
+		}
+
+		fnds := e.scanValue(path, tfAttributeName(leaf), leaf.Value)
+		if len(fnds) > 0 {
+			locate()
+		} else if leaf.Sensitive && e.terraformRule != nil {
+			locate()
+			r := e.terraformRule
+			if !isAllowlisted(e.policy, path, r.ID, leaf.Value, line, nil) {
+				fnds = append(fnds, makeFinding(path, 1, leaf.Value, leaf.Value, r.ID, r.Name, r.Severity, r.Category, nil))
+			}
+		}
+		for _, f := range fnds {
+			key := address + "|" + f.RuleID + "|" + f.RawSecret
+			if _, dup := seen[key]; dup {
+				continue
+			}
+			seen[key] = struct{}{}
+			if forced != "" {
+				f.Severity = forced
+			}
+			if !severity.MeetsOrAbove(f.Severity, e.threshold) {
+				continue
+			}
+			relocate(&f, path, address, lineNo, line)
+			f.Validation.Details["resource_address"] = leaf.Address
+			if leaf.Attribute != "" {
+				f.Validation.Details["attribute"] = leaf.Attribute
+			}
+			findings = append(findings, f)
+		}
+	}
+	return findings, true
+}
+
+// scanValue runs the line-based, JWT and connection-string rules over a value
+// extracted from a parsed file. The value is matched as "key: value" so rules
+// guarded by context such as "secret" or "password" still see the name.
+func (e *engine) scanValue(path string, key string, value string) []model.Finding {
+	if key != "" {
+		value = key + ": " + value
+	}
+	lines := strings.Split(strings.ReplaceAll(value, "\r\n", "\n"), "\n")
+	findings := e.scanMultiline(path, value)
+	for i := range lines {
+		findings = append(findings, e.scanLine(path, lines, i)...)
+	}
+	findings = append(findings, e.scanJWTs(path, lines)...)
+	return append(findings, e.scanConnectionStrings(path, lines)...)
+}
+
+// tfAttributeName is the last name in the leaf's attribute path, or the
+// output name for outputs.
+func tfAttributeName(leaf tfLeaf) string {
+	name := leaf.Attribute
+	if name == "" {
+		name = leaf.Address
+	}
+	if i := strings.LastIndex(name, "."); i >= 0 {
+		name = name[i+1:]
+	}
+	if i := strings.Index(name, "["); i >= 0 {
+		name = name[:i]
+	}
+	return name
+}
+
+func priorStateValues(doc tfDocument) *tfValues {
+	if doc.PriorState == nil {
+		return nil
+	}
+	return doc.PriorState.Values
+}
+
+func appendTFModule(leaves []tfLeaf, m tfModule) []tfLeaf {
+	for _, r := range m.Resources {
+		flattenTF(r.Address, "", r.Values, tfMirrorPaths(r.SensitiveValues, ""), &leaves)
+	}
+	for _, child := range m.ChildModules {
+		leaves = appendTFModule(leaves, child)
+	}
+	return leaves
+}
+
+func appendTFOutputs(leaves []tfLeaf, outputs map[string]tfOutput) []tfLeaf {
+	names := make([]string, 0, len(outputs))
+	for name := range outputs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		o := outputs[name]
+		var sensitive []string
+		if o.Sensitive {
+			sensitive = []string{""}
+		}
+		flattenTF("output."+name, "", o.Value, sensitive, &leaves)
+	}
+	return leaves
+}
+
+// flattenTF collects the string leaves of v. A leaf is sensitive when one of
+// the sensitive attribute paths equals its path or contains it; "" marks the
+// whole value sensitive.
+func flattenTF(address string, attr string, v any, sensitive []string, leaves *[]tfLeaf) {
+	switch t := v.(type) {
+	case map[string]any:
+		keys := make([]string, 0, len(t))
+		for k := range t {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			child := k
+			if attr != "" {
+				child = attr + "." + k
+			}
+			flattenTF(address, child, t[k], sensitive, leaves)
+		}
+	case []any:
+		for i, item := range t {
+			flattenTF(address, fmt.Sprintf("%s[%d]", attr, i), item, sensitive, leaves)
+		}
+	case string:
+		if t == "" {
+			return
+		}
+		*leaves = append(*leaves, tfLeaf{Address: address, Attribute: attr, Value: t, Sensitive: tfCovered(attr, sensitive)})
+	}
+}
+
+func tfCovered(attr string, sensitive []string) bool {
+	for _, s := range sensitive {
+		if s == "" || attr == s || strings.HasPrefix(attr, s+".") || strings.HasPrefix(attr, s+"[") {
+			return true
+		}
+	}
+	return false
+}
+
+// tfSensitivePath renders one sensitive_attributes entry, a list of get_attr
+// and index steps, as an attribute path. Newer state files store single-step
+// entries as a bare step.
+func tfSensitivePath(raw json.RawMessage) string {
+	type step struct {
+		Type  string          `json:"type"`
+		Value json.RawMessage `json:"value"`
+	}
+	var steps []step
+	if err := json.Unmarshal(raw, &steps); err != nil {
+		var single step
+		if err := json.Unmarshal(raw, &single); err != nil {
+			return ""
+		}
+		steps = []step{single}
+	}
+	path := ""
+	for _, s := range steps {
+		var key any
+		if s.Type == "index" {
+			var idx struct {
+				Value any `json:"value"`
+			}
+			if err := json.Unmarshal(s.Value, &idx); err != nil {
+				continue
+			}
+			key = idx.Value
+		} else if err := json.Unmarshal(s.Value, &key); err != nil {
+			continue
+		}
+		switch k := key.(type) {
+		case float64:
+			path += fmt.Sprintf("[%d]", int(k))
+		case string:
+			if path != "" {
+				path += "."
+			}
+			path += k
+		}
+	}
+	return path
+}
+
+// tfMirrorPaths lists the attribute paths set to true in a sensitive_values
+// or after_sensitive structure, which mirrors the shape of the values.
+func tfMirrorPaths(v any, attr string) []string {
+	switch t := v.(type) {
+	case bool:
+		if t {
+			return []string{attr}
+		}
+	case map[string]any:
+		paths := make([]string, 0)
+		for k, child := range t {
+			key := k
+			if attr != "" {
+				key = attr + "." + k
+			}
+			paths = append(paths, tfMirrorPaths(child, key)...)
+		}
+		return paths
+	case []any:
+		paths := make([]string, 0)
+		for i, child := range t {
+			paths = append(paths, tfMirrorPaths(child, fmt.Sprintf("%s[%d]", attr, i))...)
+		}
+		return paths
+	}
+	return nil
+}
+
+func tfIndexKey(key any) string {
+	switch k := key.(type) {
+	case float64:
+		return fmt.Sprintf("[%d]", int(k))
+	case string:
+		return fmt.Sprintf("[%q]", k)
+	}
+	return ""
+}
+
+// tfValueLine returns the line of the first occurrence of value as a JSON
+// string in text, or 1 when it cannot be found.
+func tfValueLine(text string, starts []int, value string) int {
+	for _, escapeHTML := range []bool{false, true} {
+		var buf bytes.Buffer
+		enc := json.NewEncoder(&buf)
+		enc.SetEscapeHTML(escapeHTML)
+		if err := enc.Encode(value); err != nil {
+			continue
+		}
+		if i := strings.Index(text, strings.TrimSuffix(buf.String(), "\n")); i >= 0 {
+			line, _ := position(starts, i)
+			return line
+		}
+	}
+	return 1
+}
@@ -0,0 +1,195 @@
+package scan
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/peter941221/secrethawk/internal/config"
+)
+
+func TestRunTerraformStateReportsByResourceAddress(t *testing.T) {
+	tmp := t.TempDir()
+	state := `{
+  "version": 4,
+  "terraform_version": "1.9.5",
+  "serial": 3,
+  "outputs": {
+    "db_password": {"value": "Zq4!rT8#mW2pLx", "type": "string", "sensitive": true},
+    "region": {"value": "eu-west-1", "type": "string"}
+  },
+  "resources": [
+    {
+      "mode": "managed",
+      "type": "aws_iam_access_key",
+      "name": "ci",
+      "provider": "provider[\"registry.terraform.io/hashicorp/aws\"]",
+      "instances": [
+        {
+          "attributes": {
+            "id": "` + testAWSKey() + `",
+            "user": "ci",
+            "secret": "wJalrXUtnFEMI/K7MDENG/bPxRfiCY8xQ2nV4kLm",
+            "ses_smtp_password_v4": "BPmk3QeX9dJt2LvR7sWn5yHc"
+          },
+          "sensitive_attributes": [
+            [{"type": "get_attr", "value": "secret"}],
+            [{"type": "get_attr", "value": "ses_smtp_password_v4"}]
+          ]
+        }
+      ]
+    },
+    {
+      "module": "module.db",
+      "mode": "managed",
+      "type": "random_password",
+      "name": "this",
+      "instances": [
+        {
+          "index_key": "primary",
+          "attributes": {"id": "none", "length": 24, "result": "h7Kp2Vw9Qx4Lm8Rt3Zc6Nb1J"},
+          "sensitive_attributes": [[{"type": "get_attr", "value": "result"}]]
+        }
+      ]
+    }
+  ]
+}
+`
+	if err := os.WriteFile(filepath.Join(tmp, "terraform.tfstate"), []byte(state), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	opts := Options{
+		Target:             tmp,
+		PolicyPath:         filepath.Join(tmp, "policy.yaml"),
+		BaselinePath:       filepath.Join(tmp, "baseline.json"),
+		Severity:           "low",
+		MaxTargetMegabytes: 5,
+		Version:            "test",
+	}
+	res, err := Run(context.Background(), opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got := map[string]string{}
+	for _, f := range res.Report.Findings {
+		address := f.Location.Address
+		if filepath.Base(f.Location.File) != "terraform.tfstate" {
+			t.Fatalf("unexpected file %q", f.Location.File)
+		}
+		got[address] = f.RuleID
+		if address == "aws_iam_access_key.ci.secret" && (f.Location.LineStart != 20 || f.Validation.Details["resource_address"] != "aws_iam_access_key.ci") {
+			t.Fatalf("unexpected secret finding: %+v", f)
+		}
+	}
+	want := map[string]string{
+		"aws_iam_access_key.ci.id":                         "aws-access-key-id",
+		"aws_iam_access_key.ci.secret":                     "aws-secret-access-key",
+		"aws_iam_access_key.ci.ses_smtp_password_v4":       "terraform-sensitive-attribute",
+		`module.db.random_password.this["primary"].result`: "terraform-sensitive-attribute",
+		"output.db_password":                               "terraform-sensitive-attribute",
+	}
+	if len(got) != len(want) {
+		t.Fatalf("expected %v, got %v", want, got)
+	}
+	for address, rule := range want {
+		if got[address] != rule {
+			t.Fatalf("expected %s at %s, got %v", rule, address, got)
+		}
+	}
+
+	policy := config.DefaultPolicy()
+	policy.Scan.Terraform.Severity = "critical"
+	opts.Policy = &policy
+	res, err = Run(context.Background(), opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, f := range res.Report.Findings {
+		if f.Severity != "critical" {
+			t.Fatalf("expected policy to force critical, got %s for %s", f.Severity, f.Location.File)
+		}
+	}
+}
+
+func TestRunTerraformPlanUsesSensitiveValues(t *testing.T) {
+	tmp := t.TempDir()
+	plan := `{"format_version":"1.2","terraform_version":"1.9.5",
+"planned_values":{"root_module":{"resources":[{"address":"aws_db_instance.main","values":{"username":"admin","password":"Xr7vQ2mK9pLw4ZtN"},"sensitive_values":{"password":true}}]}},
+"resource_changes":[{"address":"aws_db_instance.main","change":{"after":{"username":"admin","password":"Xr7vQ2mK9pLw4ZtN"},"after_sensitive":{"password":true}}}]}
+`
+	if err := os.WriteFile(filepath.Join(tmp, "plan.json"), []byte(plan), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	res, err := Run(context.Background(), Options{
+		Target:             tmp,
+		PolicyPath:         filepath.Join(tmp, "policy.yaml"),
+		BaselinePath:       filepath.Join(tmp, "baseline.json"),
+		Severity:           "low",
+		MaxTargetMegabytes: 5,
+		Version:            "test",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(res.Report.Findings) != 1 {
+		t.Fatalf("expected 1 finding, got %+v", res.Report.Findings)
+	}
+	f := res.Report.Findings[0]
+	if filepath.Base(f.Location.File) != "plan.json" || f.Location.Address != "aws_db_instance.main.password" || f.Location.LineStart != 2 || f.RuleID != "terraform-sensitive-attribute" {
+		t.Fatalf("unexpected finding: %+v", f)
+	}
+}
+
+func TestRunTerraformAllowlistMatchesStateFile(t *testing.T) {
+	tmp := t.TempDir()
+	dir := filepath.Join(tmp, "team#infra", "fixtures")
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	state := `{"version": 4, "terraform_version": "1.9.5", "resources": [
+  {"mode": "managed", "type": "random_password", "name": "p", "instances": [
+    {"attributes": {"result": "h7Kp2Vw9Qx4Lm8Rt3Zc6Nb1J"}, "sensitive_attributes": [[{"type": "get_attr", "value": "result"}]]}
+  ]}
+]}
+`
+	if err := os.WriteFile(filepath.Join(dir, "terraform.tfstate"), []byte(state), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	opts := Options{
+		Target:             tmp,
+		PolicyPath:         filepath.Join(tmp, "policy.yaml"),
+		BaselinePath:       filepath.Join(tmp, "baseline.json"),
+		Severity:           "low",
+		MaxTargetMegabytes: 5,
+		Version:            "test",
+	}
+	res, err := Run(context.Background(), opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res.Report.Findings) != 1 {
+		t.Fatalf("expected 1 finding, got %+v", res.Report.Findings)
+	}
+	loc := res.Report.Findings[0].Location
+	if !strings.HasSuffix(filepath.ToSlash(loc.File), "team#infra/fixtures/terraform.tfstate") || loc.Address != "random_password.p.result" {
+		t.Fatalf("unexpected location: %+v", loc)
+	}
+
+	policy := config.DefaultPolicy()
+	policy.Allowlist.Paths = []config.AllowPath{{Pattern: "**/fixtures/*.tfstate", Reason: "test fixtures"}}
+	opts.Policy = &policy
+	res, err = Run(context.Background(), opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res.Report.Findings) != 0 {
+		t.Fatalf("expected allowlisted state file, got %+v", res.Report.Findings)
+	}
+}
@@ -0,0 +1,56 @@
+package scan
+
+import (
+	"github.com/peter941221/secrethawk/internal/model"
+	"github.com/peter941221/secrethawk/internal/rules"
+	"github.com/peter941221/secrethawk/internal/severity"
+)
+
+// scanConnectionStrings runs the uri rules over every line. Each URI is
+// parsed; the password is the reported secret and only it is masked in the
+// redacted match, so reports still show which host is exposed.
+func (e *engine) scanConnectionStrings(path string, lines []string) []model.Finding {
+	uriRules := make([]int, 0)
+	for i, r := range e.rules {
+		if r.Type == rules.TypeURI && severity.MeetsOrAbove(r.Severity, e.threshold) {
+			uriRules = append(uriRules, i)
+		}
+	}
+	if len(uriRules) == 0 {
+		return nil
+	}
+
+	findings := make([]model.Finding, 0)
+	for idx, line := range lines {
+		candidates := e.keywords.Candidates(line)
+		for _, i := range uriRules {
+			rule := e.rules[i]
+			if !candidates[i] {
+				continue
+			}
+			for _, loc := range rule.Regex.FindAllStringIndex(line, -1) {
+				uri := line[loc[0]:loc[1]]
+				cs, ok := rules.MatchConnectionString(rule, uri)
+				if !ok {
+					continue
+				}
+				if isAllowlisted(e.policy, path, rule.ID, cs.RawPassword, line, nil) {
+					continue
+				}
+				start := loc[0] + cs.PasswordOffset
+
+				f := makeFinding(path, idx+1, cs.RawPassword, line, rule.ID, rule.Name, rule.Severity, rule.Category, nil)
+				f.Location.ColumnStart = start + 1
+				f.Location.ColumnEnd = start + len(cs.RawPassword)
+				f.Match.RawRedacted = uri[:cs.PasswordOffset] + "****" + uri[cs.PasswordOffset+len(cs.RawPassword):]
+				f.Validation.Details["scheme"] = cs.Scheme
+				f.Validation.Details["host"] = cs.Host
+				if cs.User != "" {
+					f.Validation.Details["user"] = cs.User
+				}
+				findings = append(findings, f)
+			}
+		}
+	}
+	return findings
+}
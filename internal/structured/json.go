@@ -0,0 +1,67 @@
+package structured
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+)
+
+// parseJSON streams tokens so each value keeps the line it appeared on.
+func parseJSON(data []byte) ([]Entry, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	entries := make([]Entry, 0)
+	// Token offsets only grow, so newlines are counted from the previous
+	// value instead of from the start of the file.
+	line, counted := 1, int64(0)
+	lineAt := func(offset int64) int {
+		line += bytes.Count(data[counted:offset], []byte("\n"))
+		counted = offset
+		return line
+	}
+
+	var walk func(path string) error
+	walk = func(path string) error {
+		tok, err := dec.Token()
+		if err != nil {
+			return err
+		}
+		switch t := tok.(type) {
+		case json.Delim:
+			switch t {
+			case '{':
+				for dec.More() {
+					kt, err := dec.Token()
+					if err != nil {
+						return err
+					}
+					key, ok := kt.(string)
+					if !ok {
+						return fmt.Errorf("unexpected object key %v", kt)
+					}
+					if err := walk(join(path, key)); err != nil {
+						return err
+					}
+				}
+			case '[':
+				for i := 0; dec.More(); i++ {
+					if err := walk(index(path, i)); err != nil {
+						return err
+					}
+				}
+			}
+			_, err := dec.Token()
+			return err
+		case string:
+			entries = append(entries, Entry{Path: path, Value: t, Line: lineAt(dec.InputOffset())})
+		case json.Number:
+			entries = append(entries, Entry{Path: path, Value: t.String(), Line: lineAt(dec.InputOffset())})
+		}
+		return nil
+	}
+
+	if err := walk(""); err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
@@ -0,0 +1,203 @@
+package structured
+
+import (
+	"strconv"
+	"strings"
+)
+
+// parseTOML handles the subset of TOML that holds credentials: tables, arrays
+// of tables, dotted keys, single-line strings and inline tables. Multi-line
+// strings are skipped.
+func parseTOML(data []byte) []Entry {
+	entries := make([]Entry, 0)
+	prefix := ""
+	arrays := map[string]int{}
+	inMultiline := ""
+	for i, raw := range splitLines(data) {
+		lineNo := i + 1
+		line := strings.TrimSpace(raw)
+		if inMultiline != "" {
+			if strings.Contains(line, inMultiline) {
+				inMultiline = ""
+			}
+			continue
+		}
+		line = stripComment(line, "#")
+		switch {
+		case line == "":
+		case strings.HasPrefix(line, "[["):
+			name := tomlKey(strings.TrimSuffix(strings.TrimPrefix(line, "[["), "]]"))
+			prefix = index(name, arrays[name])
+			arrays[name]++
+		case strings.HasPrefix(line, "["):
+			prefix = tomlKey(strings.TrimSuffix(strings.TrimPrefix(line, "["), "]"))
+		default:
+			key, value, ok := strings.Cut(line, "=")
+			if !ok {
+				continue
+			}
+			value = strings.TrimSpace(value)
+			for _, delim := range []string{`"""`, `'''`} {
+				if strings.HasPrefix(value, delim) && !strings.Contains(value[len(delim):], delim) {
+					inMultiline = delim
+				}
+			}
+			if inMultiline != "" {
+				continue
+			}
+			tomlValue(join(prefix, tomlKey(key)), value, lineNo, &entries)
+		}
+	}
+	return entries
+}
+
+func tomlKey(key string) string {
+	parts := strings.Split(strings.TrimSpace(key), ".")
+	for i, p := range parts {
+		parts[i] = unquote(p)
+	}
+	return strings.Join(parts, ".")
+}
+
+func tomlValue(path string, value string, lineNo int, entries *[]Entry) {
+	switch {
+	case strings.HasPrefix(value, "{") && strings.HasSuffix(value, "}"):
+		for _, pair := range splitTopLevel(value[1 : len(value)-1]) {
+			if k, v, ok := strings.Cut(pair, "="); ok {
+				tomlValue(join(path, tomlKey(k)), strings.TrimSpace(v), lineNo, entries)
+			}
+		}
+	case strings.HasPrefix(value, "[") && strings.HasSuffix(value, "]"):
+		for i, item := range splitTopLevel(value[1 : len(value)-1]) {
+			tomlValue(index(path, i), strings.TrimSpace(item), lineNo, entries)
+		}
+	case strings.HasPrefix(value, `"`):
+		if s, err := strconv.Unquote(value); err == nil {
+			value = s
+		} else {
+			value = unquote(value)
+		}
+		*entries = append(*entries, Entry{Path: path, Value: value, Line: lineNo})
+	case value == "true" || value == "false":
+	default:
+		*entries = append(*entries, Entry{Path: path, Value: unquote(value), Line: lineNo})
+	}
+}
+
+// splitTopLevel splits on commas that are not nested in quotes, brackets or
+// braces.
+func splitTopLevel(s string) []string {
+	parts := make([]string, 0)
+	depth := 0
+	var quote byte
+	start := 0
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == '{' || c == '[':
+			depth++
+		case c == '}' || c == ']':
+			depth--
+		case c == ',' && depth == 0:
+			parts = append(parts, s[start:i])
+			start = i + 1
+		}
+	}
+	if strings.TrimSpace(s[start:]) != "" {
+		parts = append(parts, s[start:])
+	}
+	return parts
+}
+
+// parseINI reads [section] headers and key = value or key: value pairs.
+func parseINI(data []byte) []Entry {
+	entries := make([]Entry, 0)
+	section := ""
+	for i, raw := range splitLines(data) {
+		line := strings.TrimSpace(raw)
+		if line == "" || line[0] == ';' || line[0] == '#' {
+			continue
+		}
+		if strings.HasPrefix(line, "[") && strings.HasSuffix(line, "]") {
+			section = strings.TrimSpace(line[1 : len(line)-1])
+			continue
+		}
+		sep := strings.IndexAny(line, "=:")
+		if sep <= 0 {
+			continue
+		}
+		key := strings.TrimSpace(line[:sep])
+		value := unquote(stripComment(line[sep+1:], ";#"))
+		entries = append(entries, Entry{Path: join(section, key), Value: value, Line: i + 1})
+	}
+	return entries
+}
+
+// parseProperties reads Java .properties files, including backslash line
+// continuations and escaped separators in keys.
+func parseProperties(data []byte) []Entry {
+	entries := make([]Entry, 0)
+	lines := splitLines(data)
+	for i := 0; i < len(lines); i++ {
+		lineNo := i + 1
+		line := strings.TrimLeft(lines[i], " \t\f")
+		if line == "" || line[0] == '#' || line[0] == '!' {
+			continue
+		}
+		for strings.HasSuffix(line, `\`) && !strings.HasSuffix(line, `\\`) && i+1 < len(lines) {
+			i++
+			line = strings.TrimSuffix(line, `\`) + strings.TrimLeft(lines[i], " \t\f")
+		}
+		sep := -1
+		for j := 0; j < len(line); j++ {
+			if line[j] == '\\' {
+				j++
+				continue
+			}
+			if line[j] == '=' || line[j] == ':' || line[j] == ' ' || line[j] == '\t' {
+				sep = j
+				break
+			}
+		}
+		key, value := line, ""
+		if sep >= 0 {
+			key = line[:sep]
+			value = strings.TrimLeft(line[sep+1:], " \t\f")
+			if line[sep] == ' ' || line[sep] == '\t' {
+				value = strings.TrimLeft(strings.TrimPrefix(strings.TrimPrefix(value, "="), ":"), " \t\f")
+			}
+		}
+		key = strings.NewReplacer(`\:`, ":", `\=`, "=", `\ `, " ").Replace(key)
+		entries = append(entries, Entry{Path: key, Value: value, Line: lineNo})
+	}
+	return entries
+}
+
+// parseDotenv reads KEY=value lines with optional export prefixes, quotes and
+// trailing comments on unquoted values.
+func parseDotenv(data []byte) []Entry {
+	entries := make([]Entry, 0)
+	for i, raw := range splitLines(data) {
+		line := strings.TrimSpace(raw)
+		if line == "" || line[0] == '#' {
+			continue
+		}
+		line = strings.TrimSpace(strings.TrimPrefix(line, "export "))
+		key, value, ok := strings.Cut(line, "=")
+		if !ok {
+			continue
+		}
+		value = strings.TrimSpace(value)
+		if !strings.HasPrefix(value, `"`) && !strings.HasPrefix(value, "'") {
+			value = stripComment(value, "#")
+		}
+		entries = append(entries, Entry{Path: strings.TrimSpace(key), Value: unquote(value), Line: i + 1})
+	}
+	return entries
+}
@@ -0,0 +1,105 @@
+// Package structured flattens configuration files into key paths and scalar
+// values so detectors can reason about what a value is named, not just what it
+// looks like.
+package structured
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// Entry is one scalar value and the dotted key path leading to it, e.g.
+// spring.datasource.password or services[0].env.API_KEY.
+type Entry struct {
+	Path  string
+	Value string
+	Line  int
+}
+
+// Format names the parser used for path, or "" for files that are not a
+// recognised config format.
+func Format(path string) string {
+	base := strings.ToLower(filepath.Base(path))
+	switch strings.ToLower(filepath.Ext(base)) {
+	case ".yaml", ".yml":
+		return "yaml"
+	case ".json":
+		return "json"
+	case ".toml":
+		return "toml"
+	case ".ini", ".cfg":
+		return "ini"
+	case ".properties":
+		return "properties"
+	}
+	if base == ".env" || strings.HasPrefix(base, ".env.") || strings.HasSuffix(base, ".env") {
+		return "dotenv"
+	}
+	return ""
+}
+
+// Parse flattens data according to the format of path.
+func Parse(path string, data []byte) ([]Entry, error) {
+	switch Format(path) {
+	case "yaml":
+		return parseYAML(data)
+	case "json":
+		return parseJSON(data)
+	case "toml":
+		return parseTOML(data), nil
+	case "ini":
+		return parseINI(data), nil
+	case "properties":
+		return parseProperties(data), nil
+	case "dotenv":
+		return parseDotenv(data), nil
+	}
+	return nil, fmt.Errorf("unsupported config format: %s", path)
+}
+
+func join(prefix string, key string) string {
+	if prefix == "" {
+		return key
+	}
+	return prefix + "." + key
+}
+
+func index(prefix string, i int) string {
+	return fmt.Sprintf("%s[%d]", prefix, i)
+}
+
+func splitLines(data []byte) []string {
+	return strings.Split(strings.ReplaceAll(string(data), "\r\n", "\n"), "\n")
+}
+
+// unquote strips one level of matching single or double quotes.
+func unquote(v string) string {
+	v = strings.TrimSpace(v)
+	if len(v) >= 2 {
+		if (v[0] == '"' && v[len(v)-1] == '"') || (v[0] == '\'' && v[len(v)-1] == '\'') {
+			return v[1 : len(v)-1]
+		}
+	}
+	return v
+}
+
+// stripComment removes a trailing comment that starts with one of markers
+// preceded by whitespace, ignoring markers inside quotes.
+func stripComment(line string, markers string) string {
+	var quote byte
+	for i := 0; i < len(line); i++ {
+		c := line[i]
+		switch {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case strings.IndexByte(markers, c) >= 0 && (i == 0 || line[i-1] == ' ' || line[i-1] == '\t'):
+			return strings.TrimSpace(line[:i])
+		}
+	}
+	return strings.TrimSpace(line)
+}
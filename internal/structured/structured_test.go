@@ -0,0 +1,48 @@
+package structured
+
+import "testing"
+
+func TestParseProducesKeyPaths(t *testing.T) {
+	cases := []struct {
+		name string
+		data string
+		path string
+		want string
+		line int
+	}{
+		{"application.yaml", "spring:\n  datasource:\n    password: hunter2-prod\n", "spring.datasource.password", "hunter2-prod", 3},
+		{"multi.yml", "a: 1\n---\nitems:\n  - token: t0k3n-value\n", "items[0].token", "t0k3n-value", 4},
+		{"config.json", "{\n  \"db\": {\n    \"db_password\": \"s3cr3t-json\"\n  }\n}\n", "db.db_password", "s3cr3t-json", 3},
+		{"app.toml", "[database]\nuser = \"app\"\npassword = \"toml-pass\" # prod\n[[servers]]\napi = { key = 'inline-key' }\n", "database.password", "toml-pass", 3},
+		{"app.toml", "[[servers]]\napi = { key = 'inline-key' }\n", "servers[0].api.key", "inline-key", 2},
+		{"settings.ini", "; comment\n[mysql]\npassword = ini-pass ; note\n", "mysql.password", "ini-pass", 3},
+		{"application.properties", "# db\nspring.datasource.password=props-pass\nlong.value = first\\\n  second\n", "spring.datasource.password", "props-pass", 2},
+		{"application.properties", "long.value = first\\\n  second\n", "long.value", "firstsecond", 1},
+		{".env.production", "# env\nexport API_TOKEN=\"dotenv-token\"\nPLAIN=abc # trailing\n", "API_TOKEN", "dotenv-token", 2},
+		{".env", "PLAIN=abc # trailing\n", "PLAIN", "abc", 1},
+	}
+	for _, tc := range cases {
+		entries, err := Parse(tc.name, []byte(tc.data))
+		if err != nil {
+			t.Fatalf("%s: %v", tc.name, err)
+		}
+		found := false
+		for _, e := range entries {
+			if e.Path == tc.path {
+				found = true
+				if e.Value != tc.want || e.Line != tc.line {
+					t.Fatalf("%s: %s = %q at line %d, want %q at line %d", tc.name, e.Path, e.Value, e.Line, tc.want, tc.line)
+				}
+			}
+		}
+		if !found {
+			t.Fatalf("%s: missing path %s in %+v", tc.name, tc.path, entries)
+		}
+	}
+}
+
+func TestFormatIgnoresUnknownFiles(t *testing.T) {
+	if Format("main.go") != "" || Format("README.md") != "" {
+		t.Fatal("expected no format for source files")
+	}
+}
@@ -0,0 +1,64 @@
+package structured
+
+import (
+	"bytes"
+	"errors"
+	"io"
+
+	"gopkg.in/yaml.v3"
+)
+
+// parseYAML walks every document in a (possibly multi-document) YAML stream.
+func parseYAML(data []byte) ([]Entry, error) {
+	docs, err := ParseYAMLDocuments(data)
+	if err != nil {
+		return nil, err
+	}
+	entries := make([]Entry, 0)
+	for _, doc := range docs {
+		entries = append(entries, doc...)
+	}
+	return entries, nil
+}
+
+// ParseYAMLDocuments flattens each document of a YAML stream separately, so
+// callers can tell which document, e.g. which Kubernetes object, a value
+// belongs to.
+func ParseYAMLDocuments(data []byte) ([][]Entry, error) {
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	docs := make([][]Entry, 0)
+	for {
+		var doc yaml.Node
+		if err := dec.Decode(&doc); err != nil {
+			if errors.Is(err, io.EOF) {
+				return docs, nil
+			}
+			return nil, err
+		}
+		entries := make([]Entry, 0)
+		walkYAML(&doc, "", &entries)
+		docs = append(docs, entries)
+	}
+}
+
+func walkYAML(n *yaml.Node, path string, entries *[]Entry) {
+	switch n.Kind {
+	case yaml.DocumentNode:
+		for _, c := range n.Content {
+			walkYAML(c, path, entries)
+		}
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(n.Content); i += 2 {
+			walkYAML(n.Content[i+1], join(path, n.Content[i].Value), entries)
+		}
+	case yaml.SequenceNode:
+		for i, c := range n.Content {
+			walkYAML(c, index(path, i), entries)
+		}
+	case yaml.ScalarNode:
+		if n.Tag == "!!null" || n.Tag == "!!bool" {
+			return
+		}
+		*entries = append(*entries, Entry{Path: path, Value: n.Value, Line: n.Line})
+	}
+}
@@ -0,0 +1,27 @@
+// Package secrethawk is the public Go API for embedding the SecretHawk
+// scanner in other programs without shelling out to the CLI.
+//
+// Build a Scanner once with New and reuse it; it is safe for concurrent use.
+// Scanners load the rule catalog compiled into the binary, so no rules/
+// directory is needed at run time. Config.RulesPath adds or overrides rules
+// from YAML on top of that catalog.
+//
+//	s, err := secrethawk.New(secrethawk.Config{MinSeverity: "high"})
+//	if err != nil {
+//		return err
+//	}
+//	report, err := s.ScanDirectory(ctx, "./service")
+//
+// Results are the same Finding and Report types the CLI writes as JSON.
+//
+// # Versioning
+//
+// APIVersion follows semantic versioning for the exported identifiers of
+// this package. Additive changes, such as new Config fields or Scanner
+// methods, bump the minor version; removing or changing the meaning of an
+// exported identifier bumps the major version. Fields added to Finding and
+// Report follow the finding-v1 JSON schema and are additive only.
+package secrethawk
+
+// APIVersion is the version of this package's API.
+const APIVersion = "1.0.0"
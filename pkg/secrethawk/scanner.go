@@ -0,0 +1,164 @@
+package secrethawk
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"runtime/debug"
+	"time"
+
+	"github.com/peter941221/secrethawk/internal/config"
+	"github.com/peter941221/secrethawk/internal/model"
+	"github.com/peter941221/secrethawk/internal/rules"
+	"github.com/peter941221/secrethawk/internal/scan"
+	"github.com/peter941221/secrethawk/internal/severity"
+)
+
+type (
+	// Finding is a single detected secret.
+	Finding = model.Finding
+	// Report holds the findings of one scan and its metadata.
+	Report = model.FindingReport
+	// Location, Match, Validation, Remediation, Suppression and Occurrence
+	// are the parts of a Finding.
+	Location    = model.Location
+	Match       = model.Match
+	Validation  = model.Validation
+	Remediation = model.Remediation
+	Suppression = model.Suppression
+	Occurrence  = model.Occurrence
+	// Metadata describes the scan that produced a Report.
+	Metadata = model.Metadata
+)
+
+// Config configures a Scanner. The zero value scans with the built-in rules,
+// the default policy and no baseline.
+type Config struct {
+	// RulesPath is a rules YAML file or directory whose rules are added to,
+	// or replace by ID, the built-in catalog.
+	RulesPath string
+	// PolicyPath is a policy.yaml; empty or missing uses the default policy.
+	PolicyPath string
+	// BaselinePath is a baseline.json whose entries are suppressed.
+	BaselinePath string
+	// MinSeverity drops findings below low, medium, high or critical.
+	// Empty reports everything.
+	MinSeverity string
+	// MaxFileMegabytes skips larger files. Zero means 50.
+	MaxFileMegabytes int
+	// Threads bounds parallel workers. Zero uses one per CPU.
+	Threads int
+	// Validate checks findings against provider APIs. This makes network
+	// calls with the detected secrets.
+	Validate bool
+	// OfflineValidation limits Validate to offline structure and checksum
+	// checks, which never leave the process.
+	OfflineValidation bool
+}
+
+// Scanner scans content with a fixed set of rules and policy.
+type Scanner struct {
+	cfg    Config
+	rules  []rules.Rule
+	policy config.Policy
+}
+
+// New loads the rules and policy named by cfg and returns a Scanner.
+func New(cfg Config) (*Scanner, error) {
+	if cfg.MinSeverity != "" {
+		v, err := severity.Normalize(cfg.MinSeverity)
+		if err != nil {
+			return nil, err
+		}
+		cfg.MinSeverity = v
+	}
+	loaded, err := rules.LoadBuiltin(cfg.RulesPath)
+	if err != nil {
+		return nil, err
+	}
+	policy, err := config.LoadPolicy(cfg.PolicyPath)
+	if err != nil {
+		return nil, err
+	}
+	return &Scanner{cfg: cfg, rules: loaded, policy: policy}, nil
+}
+
+// ScanReader scans r as a single file. path is the virtual file name used
+// for policy matching and in findings.
+func (s *Scanner) ScanReader(ctx context.Context, r io.Reader, path string) (Report, error) {
+	opts := s.options("-")
+	opts.Stdin = r
+	opts.StdinFilename = path
+	return s.run(ctx, opts)
+}
+
+// ScanBytes scans data as a single file named path.
+func (s *Scanner) ScanBytes(ctx context.Context, data []byte, path string) (Report, error) {
+	return s.ScanReader(ctx, bytes.NewReader(data), path)
+}
+
+// ScanDirectory walks dir, honouring .gitignore and .secrethawkignore files.
+func (s *Scanner) ScanDirectory(ctx context.Context, dir string) (Report, error) {
+	return s.run(ctx, s.options(dir))
+}
+
+// ScanGitRange scans the lines added by each commit in revRange, e.g.
+// "main..feature", of the repository at repo. Findings carry the commit,
+// author and containing branches.
+func (s *Scanner) ScanGitRange(ctx context.Context, repo string, revRange string) (Report, error) {
+	opts := s.options(repo)
+	opts.HistoryRange = revRange
+	return s.run(ctx, opts)
+}
+
+func (s *Scanner) options(target string) scan.Options {
+	return scan.Options{
+		Target:             target,
+		Rules:              s.rules,
+		Policy:             &s.policy,
+		PolicyPath:         s.cfg.PolicyPath,
+		BaselinePath:       s.cfg.BaselinePath,
+		Severity:           s.cfg.MinSeverity,
+		Validate:           s.cfg.Validate || s.cfg.OfflineValidation,
+		OfflineValidation:  s.cfg.OfflineValidation,
+		MaxTargetMegabytes: s.cfg.MaxFileMegabytes,
+		Threads:            s.cfg.Threads,
+		Version:            toolVersion(),
+		Now:                time.Now().UTC(),
+	}
+}
+
+// toolVersion reports the version of the secrethawk module linked into the
+// binary, which is what reports record as the scanner version. APIVersion
+// only versions this package's identifiers.
+func toolVersion() string {
+	const module = "github.com/peter941221/secrethawk"
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return "devel"
+	}
+	version := ""
+	if info.Main.Path == module {
+		version = info.Main.Version
+	}
+	for _, dep := range info.Deps {
+		if dep.Path == module {
+			version = dep.Version
+			if dep.Replace != nil && dep.Replace.Version != "" {
+				version = dep.Replace.Version
+			}
+		}
+	}
+	if version == "" || version == "(devel)" {
+		return "devel"
+	}
+	return version
+}
+
+func (s *Scanner) run(ctx context.Context, opts scan.Options) (Report, error) {
+	res, err := scan.Run(ctx, opts)
+	if err != nil {
+		return Report{}, err
+	}
+	return res.Report, nil
+}
@@ -0,0 +1,103 @@
+package secrethawk
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestScanBytesUsesEmbeddedRules(t *testing.T) {
+	// Run away from the checkout so no rules/ directory can be found on disk.
+	orig, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Chdir(orig)
+	if err := os.Chdir(t.TempDir()); err != nil {
+		t.Fatal(err)
+	}
+
+	s, err := New(Config{MinSeverity: "high"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	report, err := s.ScanBytes(context.Background(), []byte(fmt.Sprintf("aws_key = %q\n", testAWSKey())), "svc/config.py")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(report.Findings) != 1 || report.Findings[0].RuleID != "aws-access-key-id" {
+		t.Fatalf("unexpected findings: %+v", report.Findings)
+	}
+	if report.Findings[0].Location.File != "svc/config.py" {
+		t.Fatalf("expected virtual path, got %q", report.Findings[0].Location.File)
+	}
+}
+
+func TestScanGitRangeOnlyScansRange(t *testing.T) {
+	tmp := t.TempDir()
+	t.Setenv("HOME", tmp)
+	t.Setenv("GIT_CONFIG_NOSYSTEM", "1")
+	repo := filepath.Join(tmp, "repo")
+	if err := os.MkdirAll(repo, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	run := func(args ...string) string {
+		t.Helper()
+		cmd := exec.Command("git", append([]string{"-c", "user.name=test", "-c", "user.email=test@example.com"}, args...)...)
+		cmd.Dir = repo
+		out, err := cmd.CombinedOutput()
+		if err != nil {
+			t.Fatalf("git %v failed: %v output=%s", args, err, out)
+		}
+		return strings.TrimSpace(string(out))
+	}
+	commit := func(name string, content string) {
+		t.Helper()
+		if err := os.WriteFile(filepath.Join(repo, name), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		run("add", "-A")
+		run("commit", "-q", "-m", "add "+name)
+	}
+
+	run("init", "-q", "-b", "main")
+	commit("old.py", fmt.Sprintf("aws_key = %q\n", testAWSKey()))
+	base := run("rev-parse", "HEAD")
+	commit("new.py", fmt.Sprintf("x = 1\naws_key = %q\n", testAWSKey()))
+
+	s, err := New(Config{MinSeverity: "high"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	report, err := s.ScanGitRange(context.Background(), repo, base+"..HEAD")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(report.Findings) != 1 || report.Findings[0].Location.File != "new.py" || report.Findings[0].Location.LineStart != 2 {
+		t.Fatalf("unexpected findings: %+v", report.Findings)
+	}
+	if report.Findings[0].Location.Commit == nil {
+		t.Fatal("expected commit attribution")
+	}
+	if report.Metadata.Version == APIVersion {
+		t.Fatalf("report version should name the tool, not the API: %q", report.Metadata.Version)
+	}
+
+	if _, err := s.ScanGitRange(context.Background(), repo, "--output=/tmp/pwned"); err == nil {
+		t.Fatal("expected a range starting with a dash to be rejected")
+	}
+}
+
+func TestNewRejectsUnknownSeverity(t *testing.T) {
+	if _, err := New(Config{MinSeverity: "urgent"}); err == nil {
+		t.Fatal("expected severity error")
+	}
+}
+
+func testAWSKey() string {
+	return "AKIA3EXA" + "MPLE7JKXQ4F7"
+}
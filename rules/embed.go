@@ -0,0 +1,14 @@
+// Package rules embeds the default detection rule catalog so the scanner
+// works without a rules/ directory on disk.
+package rules
+
+import "embed"
+
+// Version identifies this revision of the catalog. Bump it whenever a rule
+// is added, removed or its detection changes.
+const Version = "2026.10.5"
+
+// FS holds the built-in *.yaml rule files.
+//
+//go:embed *.yaml
+var FS embed.FS